@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// getInteractiveControls counts buttons, anchors with role=button and submit, button and reset inputs by type
+func getInteractiveControls(doc *goquery.Document) map[string]int {
+	controls := map[string]int{}
+	if n := doc.Find("button").Length(); n > 0 {
+		controls["button"] = n
+	}
+	doc.Find("a[role]").Each(func(i int, s *goquery.Selection) {
+		if strings.EqualFold(strings.TrimSpace(s.AttrOr("role", "")), "button") {
+			controls["a role=button"]++
+		}
+	})
+	doc.Find("input[type]").Each(func(i int, s *goquery.Selection) {
+		switch t := strings.ToLower(strings.TrimSpace(s.AttrOr("type", ""))); t {
+		case "submit", "button", "reset":
+			controls["input "+t]++
+		}
+	})
+	return controls
+}
+
+// countMainLandmarks counts <main> elements and elements with role=main, a page should have exactly one
+func countMainLandmarks(doc *goquery.Document) int {
+	n := 0
+	doc.Find("main, [role]").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "main" || strings.EqualFold(strings.TrimSpace(s.AttrOr("role", "")), "main") {
+			n++
+		}
+	})
+	return n
+}
+
+// checkLabelTargets cross-references the for attributes of labels with element ids
+// it returns the for values without a matching id and the ids referenced by more than one label
+func checkLabelTargets(doc *goquery.Document) (dangling []string, shared []string) {
+	ids := map[string]bool{}
+	doc.Find("[id]").Each(func(i int, s *goquery.Selection) {
+		ids[s.AttrOr("id", "")] = true
+	})
+
+	dangling, shared = []string{}, []string{}
+	refs := map[string]int{}
+	doc.Find("label[for]").Each(func(i int, s *goquery.Selection) {
+		target := s.AttrOr("for", "")
+		refs[target]++
+		if !ids[target] && refs[target] == 1 {
+			dangling = append(dangling, target)
+		}
+		if ids[target] && refs[target] == 2 {
+			shared = append(shared, target)
+		}
+	})
+	return dangling, shared
+}
+
+// getAutoplayMedia lists <audio> and <video> elements with autoplay, muted videos are not listed
+// elements are described by their name and src or the src of their first <source>
+func getAutoplayMedia(doc *goquery.Document) []string {
+	media := []string{}
+	doc.Find("audio[autoplay], video[autoplay]").Each(func(i int, s *goquery.Selection) {
+		name := goquery.NodeName(s)
+		if _, muted := s.Attr("muted"); muted && name == "video" {
+			return
+		}
+		src := strings.TrimSpace(s.AttrOr("src", ""))
+		if src == "" {
+			src = strings.TrimSpace(s.Find("source[src]").First().AttrOr("src", ""))
+		}
+		if src != "" {
+			name += " " + src
+		}
+		media = append(media, name)
+	})
+	return media
+}
+
+// ariaRoles are the non-abstract roles of WAI-ARIA 1.2
+var ariaRoles = map[string]bool{}
+
+func init() {
+	for _, r := range strings.Fields(`alert alertdialog application article banner blockquote button caption cell
+		checkbox code columnheader combobox complementary contentinfo definition deletion dialog directory
+		document emphasis feed figure form generic grid gridcell group heading img insertion link list listbox
+		listitem log main marquee math menu menubar menuitem menuitemcheckbox menuitemradio meter navigation
+		none note option paragraph presentation progressbar radio radiogroup region row rowgroup rowheader
+		scrollbar search searchbox separator slider spinbutton status strong subscript superscript switch tab
+		table tablist tabpanel term textbox time timer toolbar tooltip tree treegrid treeitem`) {
+		ariaRoles[r] = true
+	}
+}
+
+// getAriaRoles counts the role values used on the page and lists the distinct values which are not ARIA roles
+// a role attribute may contain several space separated roles as fallbacks, each is counted
+func getAriaRoles(doc *goquery.Document) (map[string]int, []string) {
+	roles := map[string]int{}
+	invalid := []string{}
+	doc.Find("[role]").Each(func(i int, s *goquery.Selection) {
+		for _, r := range strings.Fields(strings.ToLower(s.AttrOr("role", ""))) {
+			roles[r]++
+			if !ariaRoles[r] && roles[r] == 1 {
+				invalid = append(invalid, r)
+			}
+		}
+	})
+	return roles, invalid
+}
+
+// getDuplicateAccesskeys returns the accesskey values assigned to more than one element, ignoring case
+// an accesskey may list several space separated alternatives, each of them counts
+func getDuplicateAccesskeys(doc *goquery.Document) []string {
+	duplicates := []string{}
+	count := map[string]int{}
+	doc.Find("[accesskey]").Each(func(i int, s *goquery.Selection) {
+		for _, k := range strings.Fields(strings.ToLower(s.AttrOr("accesskey", ""))) {
+			count[k]++
+			if count[k] == 2 {
+				duplicates = append(duplicates, k)
+			}
+		}
+	})
+	return duplicates
+}
+
+// getSVGAccessibilityIssues describes the inline <svg> elements without an accessible name, or named ones without role=img
+// the name is a non-empty <title> child, aria-label or aria-labelledby, svgs nested in svgs belong to the outer one
+// decorative svgs hidden with aria-hidden=true, also on an ancestor, or with role=presentation or none are exempt
+func getSVGAccessibilityIssues(doc *goquery.Document) []string {
+	issues := []string{}
+	doc.Find("svg").Each(func(i int, s *goquery.Selection) {
+		if s.ParentsFiltered("svg").Length() > 0 {
+			return
+		}
+		if strings.EqualFold(strings.TrimSpace(s.Closest(`[aria-hidden]`).AttrOr("aria-hidden", "")), "true") {
+			return
+		}
+		roles := strings.Fields(strings.ToLower(s.AttrOr("role", "")))
+		if len(roles) > 0 && (roles[0] == "presentation" || roles[0] == "none") {
+			return
+		}
+		named := strings.TrimSpace(s.AttrOr("aria-label", "")) != "" ||
+			strings.TrimSpace(s.AttrOr("aria-labelledby", "")) != "" ||
+			strings.TrimSpace(s.ChildrenFiltered("title").First().Text()) != ""
+		switch {
+		case !named:
+			issues = append(issues, describeSVG(s, i)+" has no accessible name")
+		case len(roles) == 0 || roles[0] != "img":
+			issues = append(issues, describeSVG(s, i)+" has no role=img")
+		}
+	})
+	return issues
+}
+
+// describeSVG identifies an svg by its id or class, or else by its position among the svgs of the page
+func describeSVG(s *goquery.Selection, i int) string {
+	if id := strings.TrimSpace(s.AttrOr("id", "")); id != "" {
+		return "svg#" + id
+	}
+	if class := strings.Fields(s.AttrOr("class", "")); len(class) > 0 {
+		return "svg." + class[0]
+	}
+	return fmt.Sprintf("svg %d", i+1)
+}
+
+// interactiveSelector matches the interactive content of HTML which may not be nested in links and buttons
+const interactiveSelector = "a[href], button, input, select, textarea, iframe, embed, details, label, audio[controls], video[controls], [tabindex]"
+
+// getNestedInteractive describes the interactive elements inside a link or button, e.g. a link in a button
+// the parser already splits directly nested <a> elements, so nested links are only found where it keeps them, like in table cells
+func getNestedInteractive(doc *goquery.Document) []string {
+	nested := []string{}
+	doc.Find(interactiveSelector).Each(func(i int, s *goquery.Selection) {
+		n := s.Get(0)
+		if n.Namespace != "" || (n.Data == "input" && strings.EqualFold(strings.TrimSpace(s.AttrOr("type", "")), "hidden")) {
+			return
+		}
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Type == html.ElementNode && p.Namespace == "" && (p.Data == "button" || (p.Data == "a" && hasAttr(p, "href"))) {
+				nested = append(nested, describeInteractive(n)+" inside "+describeInteractive(p))
+				return
+			}
+		}
+	})
+	return nested
+}
+
+// hasAttr returns true if the element n has the attribute key
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// describeInteractive identifies an element by its name and its href or type
+func describeInteractive(n *html.Node) string {
+	for _, a := range n.Attr {
+		if (n.Data == "a" && a.Key == "href") || (n.Data == "input" && a.Key == "type") {
+			return fmt.Sprintf("%s %s=%q", n.Data, a.Key, a.Val)
+		}
+	}
+	return n.Data
+}
+
+// getMissingAlt describes the images without an alt attribute by their src, an empty alt marks a decorative image
+// images hidden with aria-hidden=true or with role=presentation or none are exempt
+func getMissingAlt(doc *goquery.Document) []string {
+	missing := []string{}
+	doc.Find("img, input[type]").Each(func(i int, s *goquery.Selection) {
+		if _, ok := s.Attr("alt"); ok {
+			return
+		}
+		if goquery.NodeName(s) == "input" && !strings.EqualFold(strings.TrimSpace(s.AttrOr("type", "")), "image") {
+			return
+		}
+		if strings.EqualFold(strings.TrimSpace(s.Closest(`[aria-hidden]`).AttrOr("aria-hidden", "")), "true") {
+			return
+		}
+		if roles := strings.Fields(strings.ToLower(s.AttrOr("role", ""))); len(roles) > 0 && (roles[0] == "presentation" || roles[0] == "none") {
+			return
+		}
+		desc := goquery.NodeName(s)
+		if src := strings.TrimSpace(s.AttrOr("src", "")); src != "" {
+			desc += " " + src
+		}
+		missing = append(missing, desc)
+	})
+	return missing
+}
+
+// missingSkipLink returns true if the page has a navigation but its first link does not skip to an element of the page
+func missingSkipLink(doc *goquery.Document) bool {
+	nav := doc.Find("nav").Length() > 0
+	doc.Find("[role]").Each(func(i int, s *goquery.Selection) {
+		nav = nav || contains(strings.Fields(strings.ToLower(s.AttrOr("role", ""))), "navigation")
+	})
+	if !nav {
+		return false
+	}
+	href := strings.TrimSpace(doc.Find("a[href]").First().AttrOr("href", ""))
+	if !strings.HasPrefix(href, "#") || len(href) == 1 {
+		return true
+	}
+	id := href[1:]
+	found := false
+	doc.Find("[id], a[name]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		found = s.AttrOr("id", "") == id || (goquery.NodeName(s) == "a" && s.AttrOr("name", "") == id)
+		return !found
+	})
+	return !found
+}
+
+// getAutofocus describes the elements with autofocus, which move the focus on load past the content before them
+func getAutofocus(doc *goquery.Document) []string {
+	autofocus := []string{}
+	doc.Find("[autofocus]").Each(func(i int, s *goquery.Selection) {
+		autofocus = append(autofocus, describeInteractive(s.Get(0)))
+	})
+	return autofocus
+}
+
+// getSkippedHeadingLevels describes the headings which skip a level after the previous heading, e.g. "h1 -> h3"
+// a heading may go back to any higher level
+func getSkippedHeadingLevels(doc *goquery.Document) []string {
+	skipped := []string{}
+	previous := 0
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+		level := int(goquery.NodeName(s)[1] - '0')
+		if previous > 0 && level > previous+1 {
+			skipped = append(skipped, fmt.Sprintf("h%d -> h%d %q", previous, level, strings.TrimSpace(s.Text())))
+		}
+		previous = level
+	})
+	return skipped
+}
+
+// getUnlabeledFields describes the form fields without a label, a field is labeled by a <label for> its id,
+// a wrapping <label>, aria-label or aria-labelledby, hidden inputs and buttons need no label
+func getUnlabeledFields(doc *goquery.Document) []string {
+	labeled := map[string]bool{}
+	doc.Find("label[for]").Each(func(i int, s *goquery.Selection) {
+		labeled[s.AttrOr("for", "")] = true
+	})
+	unlabeled := []string{}
+	doc.Find("input, select, textarea").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "input" {
+			switch strings.ToLower(strings.TrimSpace(s.AttrOr("type", ""))) {
+			case "hidden", "submit", "button", "reset", "image":
+				return
+			}
+		}
+		if id := s.AttrOr("id", ""); id != "" && labeled[id] {
+			return
+		}
+		if s.Closest("label").Length() > 0 ||
+			strings.TrimSpace(s.AttrOr("aria-label", "")) != "" ||
+			strings.TrimSpace(s.AttrOr("aria-labelledby", "")) != "" {
+			return
+		}
+		desc := goquery.NodeName(s)
+		if name := strings.TrimSpace(s.AttrOr("name", "")); name != "" {
+			desc += fmt.Sprintf(" name=%q", name)
+		}
+		unlabeled = append(unlabeled, desc)
+	})
+	return unlabeled
+}
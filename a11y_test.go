@@ -0,0 +1,210 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInteractiveControls(t *testing.T) {
+	got := fetch(loadFixture(t, "controls.html")).interactiveControls
+	want := map[string]int{
+		"button":        2,
+		"a role=button": 1,
+		"input submit":  2,
+		"input reset":   1,
+		"input button":  1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMainLandmark(t *testing.T) {
+	tests := []struct {
+		fixture string
+		count   int
+		issue   bool
+	}{
+		{"plain.html", 0, true},
+		{"main_one.html", 1, false},
+		{"main_two.html", 2, true},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if fr.mainLandmarks != tt.count {
+			t.Errorf("%s: expected %d main landmarks, got %d", tt.fixture, tt.count, fr.mainLandmarks)
+		}
+		if hasFinding(fr, "MainLandmarkIssue") != tt.issue {
+			t.Errorf("%s: expected MainLandmarkIssue %t", tt.fixture, tt.issue)
+		}
+	}
+}
+
+func TestLabelTargets(t *testing.T) {
+	fr := fetch(loadFixture(t, "labels.html"))
+	if !reflect.DeepEqual(fr.danglingLabels, []string{"phone"}) {
+		t.Errorf("expected the label for phone to be dangling, got %v", fr.danglingLabels)
+	}
+	if !reflect.DeepEqual(fr.sharedLabelTargets, []string{"terms"}) {
+		t.Errorf("expected terms to be referenced by more than one label, got %v", fr.sharedLabelTargets)
+	}
+	if !hasFinding(fr, "DanglingLabel") || !hasFinding(fr, "SharedLabelTarget") {
+		t.Errorf("expected DanglingLabel and SharedLabelTarget findings, got %v", fr.findings())
+	}
+}
+
+func TestAutoplayMedia(t *testing.T) {
+	fr := fetch(loadFixture(t, "autoplay.html"))
+	if !reflect.DeepEqual(fr.autoplayMedia, []string{"video /trailer.mp4"}) {
+		t.Errorf("expected only the unmuted autoplay video, got %v", fr.autoplayMedia)
+	}
+	if !hasFinding(fr, "AutoplayMedia") {
+		t.Errorf("expected an AutoplayMedia finding, got %v", fr.findings())
+	}
+}
+
+func TestAriaRoles(t *testing.T) {
+	fr := fetch(loadFixture(t, "aria_roles.html"))
+	want := map[string]int{
+		"banner":     1,
+		"navigation": 1,
+		"main":       1,
+		"button":     1,
+		"buton":      2,
+		"switch":     1,
+		"checkbox":   1,
+		"dropdown":   1,
+	}
+	if !reflect.DeepEqual(fr.ariaRoles, want) {
+		t.Errorf("expected roles %v, got %v", want, fr.ariaRoles)
+	}
+	if !reflect.DeepEqual(fr.invalidAriaRoles, []string{"buton", "dropdown"}) {
+		t.Errorf("expected buton and dropdown to be invalid, got %v", fr.invalidAriaRoles)
+	}
+	if !hasFinding(fr, "InvalidAriaRoles") {
+		t.Errorf("expected an InvalidAriaRoles finding, got %v", fr.findings())
+	}
+}
+
+func TestDuplicateAccesskeys(t *testing.T) {
+	fr := fetch(loadFixture(t, "accesskeys.html"))
+	if !reflect.DeepEqual(fr.duplicateAccesskeys, []string{"s"}) {
+		t.Errorf("expected accesskey s to be duplicated, got %v", fr.duplicateAccesskeys)
+	}
+	if !hasFinding(fr, "DuplicateAccesskeys") {
+		t.Errorf("expected a DuplicateAccesskeys finding, got %v", fr.findings())
+	}
+}
+
+func TestSVGAccessibilityIssues(t *testing.T) {
+	fr := fetch(loadFixture(t, "svg.html"))
+	want := []string{
+		"svg.icon-search has no accessible name",
+		"svg#mascot has no accessible name",
+		"svg#badge has no role=img",
+	}
+	if !reflect.DeepEqual(fr.svgIssues, want) {
+		t.Errorf("expected %v, got %v", want, fr.svgIssues)
+	}
+	if !hasFinding(fr, "SvgAccessibilityIssues") {
+		t.Errorf("expected a SvgAccessibilityIssues finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "SvgAccessibilityIssues") {
+		t.Error("expected no SvgAccessibilityIssues finding without svgs")
+	}
+}
+
+func TestNestedInteractive(t *testing.T) {
+	fr := fetch(loadFixture(t, "nested_interactive.html"))
+	//hidden inputs, inputs in labels and links in details are allowed
+	want := []string{
+		`a href="/products/lamp/reviews" inside a href="/products/lamp"`,
+		`a href="/options" inside button`,
+		`button inside a href="/cart"`,
+	}
+	if !reflect.DeepEqual(fr.nestedInteractive, want) {
+		t.Errorf("expected %v, got %v", want, fr.nestedInteractive)
+	}
+	if !hasFinding(fr, "NestedInteractive") {
+		t.Errorf("expected a NestedInteractive finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "NestedInteractive") {
+		t.Error("expected no NestedInteractive finding for a plain page")
+	}
+}
+
+func TestMissingAlt(t *testing.T) {
+	fr := fetch(loadFixture(t, "images_alt.html"))
+	//empty alts and hidden images are decorative
+	want := []string{"img /img/beach.jpg", "input /img/go.png"}
+	if !reflect.DeepEqual(fr.missingAlt, want) {
+		t.Errorf("expected %v, got %v", want, fr.missingAlt)
+	}
+	if !hasFinding(fr, "MissingAlt") {
+		t.Errorf("expected a MissingAlt finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "skip_link.html")); hasFinding(fr, "MissingAlt") {
+		t.Errorf("expected no MissingAlt finding, got %v", fr.missingAlt)
+	}
+}
+
+func TestMissingSkipLink(t *testing.T) {
+	tests := []struct {
+		fixture string
+		missing bool
+	}{
+		{"images_alt.html", true},
+		{"skip_link.html", false},
+		//a page without navigation needs no skip link
+		{"plain.html", false},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if fr.missingSkipLink != tt.missing || hasFinding(fr, "MissingSkipLink") != tt.missing {
+			t.Errorf("%s: expected MissingSkipLink %t, got %v", tt.fixture, tt.missing, fr.findings())
+		}
+	}
+}
+
+func TestAutofocus(t *testing.T) {
+	fr := fetch(loadFixture(t, "images_alt.html"))
+	if !reflect.DeepEqual(fr.autofocus, []string{`input type="search"`}) {
+		t.Errorf("expected the search input, got %v", fr.autofocus)
+	}
+	if !hasFinding(fr, "Autofocus") {
+		t.Errorf("expected an Autofocus finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "Autofocus") {
+		t.Error("expected no Autofocus finding for a plain page")
+	}
+}
+
+func TestSkippedHeadingLevels(t *testing.T) {
+	fr := fetch(loadFixture(t, "form_fields.html"))
+	//going back from h3 to h2 is no skip
+	want := []string{`h1 -> h3 "Write to us"`, `h2 -> h4 "Post"`}
+	if !reflect.DeepEqual(fr.skippedHeadings, want) {
+		t.Errorf("expected %v, got %v", want, fr.skippedHeadings)
+	}
+	if !hasFinding(fr, "SkippedHeadingLevels") {
+		t.Errorf("expected a SkippedHeadingLevels finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "toc.html")); hasFinding(fr, "SkippedHeadingLevels") {
+		t.Errorf("expected no SkippedHeadingLevels finding, got %v", fr.skippedHeadings)
+	}
+}
+
+func TestUnlabeledFields(t *testing.T) {
+	fr := fetch(loadFixture(t, "form_fields.html"))
+	//a placeholder is no label, hidden inputs and buttons need none
+	want := []string{`input name="phone"`, `textarea name="message"`}
+	if !reflect.DeepEqual(fr.unlabeledFields, want) {
+		t.Errorf("expected %v, got %v", want, fr.unlabeledFields)
+	}
+	if !hasFinding(fr, "UnlabeledFields") {
+		t.Errorf("expected an UnlabeledFields finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "UnlabeledFields") {
+		t.Error("expected no UnlabeledFields finding without forms")
+	}
+}
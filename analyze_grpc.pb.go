@@ -0,0 +1,99 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package main
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// AnalyzerClient is the client API for Analyzer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalyzerClient interface {
+	// Analyze fetches url, checks its internal links and returns the report
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*Report, error)
+}
+
+type analyzerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyzerClient(cc grpc.ClientConnInterface) AnalyzerClient {
+	return &analyzerClient{cc}
+}
+
+func (c *analyzerClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*Report, error) {
+	out := new(Report)
+	err := c.cc.Invoke(ctx, "/goweb.Analyzer/Analyze", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalyzerServer is the server API for Analyzer service.
+// All implementations must embed UnimplementedAnalyzerServer
+// for forward compatibility
+type AnalyzerServer interface {
+	// Analyze fetches url, checks its internal links and returns the report
+	Analyze(context.Context, *AnalyzeRequest) (*Report, error)
+	mustEmbedUnimplementedAnalyzerServer()
+}
+
+// UnimplementedAnalyzerServer must be embedded to have forward compatible implementations.
+type UnimplementedAnalyzerServer struct {
+}
+
+func (UnimplementedAnalyzerServer) Analyze(context.Context, *AnalyzeRequest) (*Report, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedAnalyzerServer) mustEmbedUnimplementedAnalyzerServer() {}
+
+// UnsafeAnalyzerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyzerServer will
+// result in compilation errors.
+type UnsafeAnalyzerServer interface {
+	mustEmbedUnimplementedAnalyzerServer()
+}
+
+func RegisterAnalyzerServer(s grpc.ServiceRegistrar, srv AnalyzerServer) {
+	s.RegisterService(&_Analyzer_serviceDesc, srv)
+}
+
+func _Analyzer_Analyze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyzerServer).Analyze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goweb.Analyzer/Analyze",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyzerServer).Analyze(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Analyzer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goweb.Analyzer",
+	HandlerType: (*AnalyzerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyze",
+			Handler:    _Analyzer_Analyze_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "analyze.proto",
+}
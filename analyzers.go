@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// analyzer fills the fields of a fetchResult it is responsible for from the document
+type analyzer struct {
+	name    string
+	analyze func(doc *goquery.Document, fr *fetchResult)
+}
+
+// responseAnalyzer is an analyzer which also needs the response, it is run by analyzeDocument
+type responseAnalyzer struct {
+	name    string
+	analyze func(ctx context.Context, doc *goquery.Document, res *http.Response, fr *fetchResult)
+}
+
+// analyzers are run by fetch in order, see --checks
+var analyzers = []analyzer{
+	{"meta", func(doc *goquery.Document, fr *fetchResult) {
+		v, err := versionReader(doc)
+		if err != nil {
+			log.Println("Error loading version", err)
+		}
+		fr.version = v
+		fr.missingDoctype = !hasDoctype(doc)
+		fr.title = doc.Find("title").Contents().Text()
+		if doc.Url != nil {
+			fr.pathDepth = pathDepth(doc.Url)
+			fr.deepURL = limits.maxPathDepth > 0 && fr.pathDepth > limits.maxPathDepth
+		}
+		fr.metaDescription, fr.hasMetaDescription = getMetaDescription(doc)
+		fr.metaDescriptionIssue = metaDescriptionIssue(fr.metaDescription, fr.hasMetaDescription)
+		fr.canonical = getCanonical(doc)
+		fr.ogURL = strings.TrimSpace(doc.Find(`meta[property="og:url"]`).AttrOr("content", ""))
+		fr.canonicalOgURLMismatch = fr.canonical != "" && fr.ogURL != "" && !sameURL(doc, fr.canonical, fr.ogURL)
+	}},
+	{"headings", func(doc *goquery.Document, fr *fetchResult) {
+		fr.headings = getHeadings(doc)
+		fr.h1Texts = getH1Texts(doc)
+		fr.missingH1 = len(fr.h1Texts) == 0
+		fr.multipleH1 = len(fr.h1Texts) > 1
+		fr.toc = getTOCEntries(doc)
+	}},
+	{"content", func(doc *goquery.Document, fr *fetchResult) {
+		fr.wordCount = len(strings.Fields(visibleText(doc)))
+		fr.thinContent = limits.minWords > 0 && fr.wordCount < limits.minWords
+	}},
+	{"links", func(doc *goquery.Document, fr *fetchResult) {
+		found := collectURLs(doc)
+		fr.urls, fr.deprecatedProtocolLinks = found.urls, found.deprecated
+		fr.downloads = getDownloads(fr.urls)
+		if base := documentBase(doc); base != doc.Url {
+			fr.base = base.String()
+		}
+		fr.malformedLinks = getMalformedLinks(documentBase(doc), fr.urls)
+		fr.rawURLAnchors = getRawURLAnchors(doc)
+		fr.placeholderLinks = countPlaceholderLinks(doc)
+		fr.paginationNext, fr.paginationPrev = getPagination(doc)
+	}},
+	{"a11y", func(doc *goquery.Document, fr *fetchResult) {
+		fr.interactiveControls = getInteractiveControls(doc)
+		fr.mainLandmarks = countMainLandmarks(doc)
+		fr.danglingLabels, fr.sharedLabelTargets = checkLabelTargets(doc)
+		fr.autoplayMedia = getAutoplayMedia(doc)
+		fr.ariaRoles, fr.invalidAriaRoles = getAriaRoles(doc)
+		fr.lowContrast = getLowContrast(doc)
+		fr.duplicateAccesskeys = getDuplicateAccesskeys(doc)
+		fr.svgIssues = getSVGAccessibilityIssues(doc)
+		fr.nestedInteractive = getNestedInteractive(doc)
+		fr.missingAlt = getMissingAlt(doc)
+		fr.missingSkipLink = missingSkipLink(doc)
+		fr.autofocus = getAutofocus(doc)
+		fr.skippedHeadings = getSkippedHeadingLevels(doc)
+		fr.unlabeledFields = getUnlabeledFields(doc)
+	}},
+	{"mobile", func(doc *goquery.Document, fr *fetchResult) {
+		fr.zoomDisabled = viewportZoomDisabled(doc)
+		fr.hasViewport, fr.viewportIssues = getViewportIssues(doc)
+		fr.wideElements = getWideElements(doc)
+	}},
+	{"perf", func(doc *goquery.Document, fr *fetchResult) {
+		fr.renderBlockingCSS = countRenderBlockingCSS(doc)
+		fr.estimatedRequests = len(getResources(doc))
+		fr.resourceHints, fr.preloadsMissingAs = getResourceHints(doc)
+		fr.fonts = getFonts(doc)
+		fr.excessiveFonts = limits.maxFonts > 0 && len(fr.fonts) > limits.maxFonts
+	}},
+	{"privacy", func(doc *goquery.Document, fr *fetchResult) {
+		fr.trackingPixels = getTrackingPixels(doc)
+		fr.hosts, fr.thirdPartyHosts = getHosts(doc)
+	}},
+	{"pwa", func(doc *goquery.Document, fr *fetchResult) {
+		fr.manifestURL, fr.hasManifest = getManifest(doc)
+		fr.hasServiceWorker = registersServiceWorker(doc)
+	}},
+	{"structured-data", func(doc *goquery.Document, fr *fetchResult) {
+		fr.structuredData = getStructuredData(doc)
+	}},
+	{"legacy", func(doc *goquery.Document, fr *fetchResult) {
+		fr.comments, fr.conditionalComments = countComments(doc)
+		fr.xhtmlNamespace = declaresXHTMLNamespace(doc)
+		fr.presentationalAttributes = countPresentationalAttributes(doc)
+	}},
+}
+
+// responseAnalyzers are run by analyzeDocument after fetch
+var responseAnalyzers = []responseAnalyzer{
+	{"robots", func(ctx context.Context, doc *goquery.Document, res *http.Response, fr *fetchResult) {
+		fr.crawlable, fr.crawlReason = checkCrawlable(ctx, res.Request.URL, res.Header, doc)
+	}},
+	{"cache", func(ctx context.Context, doc *goquery.Document, res *http.Response, fr *fetchResult) {
+		fr.cache = parseCacheHeaders(res.Header)
+		fr.etag, fr.lastModified = res.Header.Get("ETag"), res.Header.Get("Last-Modified")
+	}},
+	{"security", func(ctx context.Context, doc *goquery.Document, res *http.Response, fr *fetchResult) {
+		fr.upgradeInsecureRequests = checkUpgradeInsecure(doc, res)
+		fr.insecureHops = insecureRedirectHops(fr.redirects, fr.url)
+		fr.formsWithoutCSRFToken = getFormsWithoutCSRFToken(doc)
+		fr.missingSRI = getMissingSRI(doc)
+	}},
+}
+
+// enabledChecks are the analyzers run by fetch and analyzeDocument, nil runs all, run sets it from --checks
+var enabledChecks map[string]bool
+
+// checkNames returns the names of all analyzers, sorted
+func checkNames() []string {
+	names := []string{}
+	for _, a := range analyzers {
+		names = append(names, a.name)
+	}
+	for _, a := range responseAnalyzers {
+		names = append(names, a.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseChecks returns the enabledChecks of a comma separated list of analyzer names, "all" enables all
+func parseChecks(list string) (map[string]bool, error) {
+	enabled := map[string]bool{}
+	names := checkNames()
+	for _, c := range strings.Split(list, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "all" {
+			return nil, nil
+		}
+		if !contains(names, c) {
+			return nil, fmt.Errorf("unknown check %q, valid checks are all, %s", c, strings.Join(names, ", "))
+		}
+		enabled[c] = true
+	}
+	return enabled, nil
+}
+
+// profile is a preset of analyzers for a focused audit, see --profile
+type profile struct {
+	checks []string
+	//title is printed above the text output
+	title string
+}
+
+// profiles are the valid values of --profile
+var profiles = map[string]profile{
+	//headings contains the h1 checks and mobile the zoom and width checks, which are accessibility issues as well
+	"a11y": {checks: []string{"a11y", "headings", "mobile"}, title: "Accessibility audit"},
+}
+
+// profileNames returns the names of the profiles, sorted
+func profileNames() []string {
+	names := []string{}
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileChecks returns the --checks list of a profile
+// an explicit list of checks narrows the profile, it may only contain checks of the profile
+func profileChecks(name, checks string, explicit bool) (string, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown profile %q, valid profiles are %s", name, strings.Join(profileNames(), ", "))
+	}
+	if !explicit {
+		return strings.Join(p.checks, ","), nil
+	}
+	for _, c := range strings.Split(checks, ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); !contains(p.checks, c) {
+			return "", fmt.Errorf("check %q is not part of profile %s, which runs %s", c, name, strings.Join(p.checks, ", "))
+		}
+	}
+	return checks, nil
+}
+
+// checkEnabled returns true if the analyzer name runs
+func checkEnabled(name string) bool {
+	return enabledChecks == nil || enabledChecks[name]
+}
+
+// skip records that the analyzer name did not run for fr
+func (fr *fetchResult) skip(name string) {
+	if fr.skipped == nil {
+		fr.skipped = map[string]bool{}
+	}
+	fr.skipped[name] = true
+}
+
+// ran returns true unless the analyzer name was skipped for fr
+// fetchResults built without fetch, e.g. in tests, have all checks
+func (fr *fetchResult) ran(name string) bool {
+	return !fr.skipped[name]
+}
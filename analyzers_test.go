@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChecks(t *testing.T) {
+	defer func() { enabledChecks = nil }()
+	var err error
+	enabledChecks, err = parseChecks("headings, links")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr := fetch(loadFixture(t, "broken/index.html"))
+	if fr.headings["h1"] != 1 || len(fr.urls) != 3 {
+		t.Errorf("expected headings and links to be analyzed, got %v and %v", fr.headings, fr.urls)
+	}
+	if fr.title != "" || fr.wordCount != 0 || fr.interactiveControls != nil || len(fr.structuredData.formats()) != 0 {
+		t.Errorf("expected disabled analyzers not to populate their fields, got %+v", fr)
+	}
+	if fr.ran("a11y") || !fr.ran("headings") {
+		t.Errorf("expected a11y to be skipped and headings to run, skipped %v", fr.skipped)
+	}
+	if f := fr.findings(); len(f) != 0 {
+		t.Errorf("expected no findings of disabled analyzers, got %v", f)
+	}
+}
+
+func TestChecksOption(t *testing.T) {
+	ts := fixtureServer(map[string]string{"/": "testdata/broken/index.html", "/ok": "testdata/broken/ok.html"})
+	defer ts.Close()
+	defer func() { enabledChecks = nil }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--checks", "headings", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "Headings count by level:\n") || strings.Contains(out, "Website title") || strings.Contains(out, "inaccessible") {
+		t.Errorf("expected only headings, got\n%s", out)
+	}
+
+	if code := run([]string{"--checks", "headings,spelling", ts.URL}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d for an unknown check, got %d", exitUsage, code)
+	}
+}
+
+func TestA11yProfile(t *testing.T) {
+	//presentational.html also has obsolete attributes and no meta description, which are no a11y findings
+	ts := fixtureServer(map[string]string{"/": "testdata/presentational.html"})
+	defer ts.Close()
+	defer func() { enabledChecks = nil }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--profile", "a11y", "--format", "json", ts.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Findings) == 0 {
+		t.Fatal("expected a11y findings")
+	}
+	for _, f := range rep.Findings {
+		if scoreRules[f.Kind].category != "accessibility" {
+			t.Errorf("expected only accessibility findings, got %s", f.Kind)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--profile", "a11y", ts.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.HasPrefix(out, "Accessibility audit of "+ts.URL+"/\n") || strings.Contains(out, "found") {
+		t.Errorf("expected an accessibility audit without links, got\n%s", out)
+	}
+
+	//the profile checks the headings, a missing h1 is reported although it is scored under seo
+	h1 := fixtureServer(map[string]string{"/": "testdata/h1_none.html"})
+	defer h1.Close()
+	stdout.Reset()
+	if code := run([]string{"--profile", "a11y", "--format", "json", h1.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"MissingH1"`) {
+		t.Errorf("expected a MissingH1 finding, got %s", stdout.String())
+	}
+
+	//skipped heading levels and unlabeled form fields are accessibility findings
+	form := fixtureServer(map[string]string{"/": "testdata/form_fields.html"})
+	defer form.Close()
+	stdout.Reset()
+	if code := run([]string{"--profile", "a11y", "--format", "json", form.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, kind := range []string{"SkippedHeadingLevels", "UnlabeledFields"} {
+		if !strings.Contains(stdout.String(), `"`+kind+`"`) || scoreRules[kind].category != "accessibility" {
+			t.Errorf("expected an accessibility finding %s, got %s", kind, stdout.String())
+		}
+	}
+
+	//--checks narrows the profile but can not extend it
+	if _, err := parseOptions([]string{"--profile", "a11y", "--checks", "a11y", ts.URL}, &stderr); err != nil {
+		t.Errorf("expected --checks within the profile to be valid, got %v", err)
+	}
+	if _, err := parseOptions([]string{"--profile", "a11y", "--checks", "a11y,links", ts.URL}, &stderr); err == nil {
+		t.Error("expected an error for a check outside of the profile")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// loadBaseline reads a previous --format json report and returns its links which were OK by url
+// only those are known-good, the others are checked again
+func loadBaseline(path string) (map[string]linkResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rep jsonReport
+	if err := json.NewDecoder(f).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("Error parsing baseline %s: %v", path, err)
+	}
+	known := map[string]linkResult{}
+	for _, l := range rep.Links.Checked {
+		if l.Status == linkOK.String() {
+			known[l.URL] = linkResult{url: l.URL, status: linkOK, code: l.Code}
+		}
+	}
+	return known, nil
+}
+
+// loadCrawlBaseline reads a previous --crawl --format json report and returns the urls of its pages
+func loadCrawlBaseline(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rep jsonCrawlReport
+	if err := json.NewDecoder(f).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("Error parsing previous crawl %s: %v", path, err)
+	}
+	urls := []string{}
+	for _, p := range rep.Pages {
+		urls = append(urls, p.URL)
+	}
+	return urls, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestSince(t *testing.T) {
+	var mu sync.Mutex
+	pinged := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<!DOCTYPE html><html><head><title>Baseline</title></head><body>
+				<a href="/known">Known</a><a href="/failed">Failed</a><a href="/new">New</a></body></html>`))
+			return
+		case "/robots.txt":
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		pinged = append(pinged, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	baseline := jsonReport{Links: jsonLinks{Checked: []jsonLink{
+		{URL: ts.URL + "/known", Status: "OK", Code: 200},
+		{URL: ts.URL + "/failed", Status: "Down", Code: 503},
+	}}}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	b, _ := json.Marshal(baseline)
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--since", path, "--format", "json", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	sort.Strings(pinged)
+	if want := []string{"/failed", "/new"}; !reflect.DeepEqual(pinged, want) {
+		t.Errorf("expected only %v to be pinged, got %v", want, pinged)
+	}
+
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]string{}
+	for _, l := range rep.Links.Checked {
+		statuses[l.URL] = l.Status
+	}
+	want := map[string]string{ts.URL + "/known": "OK", ts.URL + "/failed": "OK", ts.URL + "/new": "OK"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("expected the known link to be merged into the report, got %v", statuses)
+	}
+}
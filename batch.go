@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// batchColumns are appended to every row of the --input-csv
+var batchColumns = []string{"status", "broken_links", "warnings", "error"}
+
+// auditCSV analyzes the url in column of every row of r and writes the rows to w with batchColumns appended
+// a page which can not be analyzed gets its error in the error column, the other rows are still audited
+func auditCSV(ctx context.Context, r io.Reader, w io.Writer, column string, checker *linkChecker) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("Error reading input CSV: %v", err)
+	}
+	col := -1
+	for i, h := range header {
+		if h == column {
+			col = i
+		}
+	}
+	if col < 0 {
+		return fmt.Errorf("input CSV has no column %q", column)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(append(header, batchColumns...))
+	for ctx.Err() == nil {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading input CSV: %v", err)
+		}
+		cw.Write(append(row, auditRow(ctx, row[col], checker)...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// auditRow returns the batchColumns values of pageURL
+func auditRow(ctx context.Context, pageURL string, checker *linkChecker) []string {
+	doc, res, err := parsePage(ctx, pageURL)
+	status := ""
+	if res != nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	if err != nil {
+		return []string{status, "", "", err.Error()}
+	}
+	fr := analyzeDocument(ctx, doc, res)
+	r := sortLinks(ctx, fr.urls, fr.linkBase(), checker)
+	return []string{status, strconv.Itoa(r.inaccessible), strconv.Itoa(len(fr.findings())), ""}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestInputCSV(t *testing.T) {
+	ts := fixtureServer(map[string]string{"/": "testdata/broken/index.html", "/ok": "testdata/broken/ok.html"})
+	defer ts.Close()
+
+	input := filepath.Join(t.TempDir(), "pages.csv")
+	rows := "team,url\nshop," + ts.URL + "/\nblog," + ts.URL + "/gone\n"
+	if err := ioutil.WriteFile(input, []byte(rows), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--input-csv", input, "--url-column", "url"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	want := "team,url,status,broken_links,warnings,error\n" +
+		"shop," + ts.URL + "/,200,1,2,\n" +
+		"blog," + ts.URL + "/gone,404,,,Error response status code was 404\n"
+	if stdout.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, stdout.String())
+	}
+}
+
+func TestInputCSVMissingColumn(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "pages.csv")
+	if err := ioutil.WriteFile(input, []byte("team,link\nshop,http://example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if code := run([]string{"--input-csv", input}, ioutil.Discard, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for a missing url column, got %d", exitError, code)
+	}
+}
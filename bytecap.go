@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// totalBytes caps the body bytes read across the whole run, nil without --max-total-bytes
+// run sets it, timedBody counts the bytes of every response sent by doRequest
+var totalBytes *byteBudget
+
+// errMaxTotalBytes is returned by doRequest for requests after the cap was exceeded
+var errMaxTotalBytes = errors.New("max total bytes reached")
+
+// byteBudget counts downloaded bytes, exceeding max cancels the run so no new pages or links are requested
+// it is safe for concurrent use
+type byteBudget struct {
+	used   int64 //first for 64-bit alignment of the atomic operations
+	max    int64
+	cancel context.CancelFunc
+}
+
+func newByteBudget(max int64, cancel context.CancelFunc) *byteBudget {
+	return &byteBudget{max: max, cancel: cancel}
+}
+
+// add counts n downloaded bytes
+func (b *byteBudget) add(n int) {
+	if atomic.AddInt64(&b.used, int64(n)) > b.max {
+		b.cancel()
+	}
+}
+
+// exceeded returns true once more than max bytes were downloaded
+func (b *byteBudget) exceeded() bool {
+	return b.consumed() > b.max
+}
+
+// consumed returns the number of bytes downloaded so far
+func (b *byteBudget) consumed() int64 {
+	return atomic.LoadInt64(&b.used)
+}
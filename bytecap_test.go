@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxTotalBytesPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		//the page is streamed in chunks, so it is still read after the cap was exceeded
+		fmt.Fprint(w, "<html><head><title>Large page</title></head><body>\n")
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(w, "<p>%s</p>\n", strings.Repeat("word ", 200))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	defer ts.Close()
+	defer func() { totalBytes = nil }()
+
+	//the page alone exceeds the cap, it is still reported
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--format", "json", "--max-total-bytes", "1000", ts.URL + "/"}, &stdout, &stderr)
+	if code != exitMaxTotalBytes {
+		t.Fatalf("expected exit code %d, got %d: %s", exitMaxTotalBytes, code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatalf("expected the page report, got %q: %v", stdout.String(), err)
+	}
+	if rep.URL != ts.URL+"/" || rep.Title != "Large page" {
+		t.Errorf("expected the analyzed page, got %+v", rep)
+	}
+}
+
+func TestMaxTotalBytesLinkBodies(t *testing.T) {
+	var served int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><head><title>Downloads</title></head><body><a href="/a">a</a> <a href="/b">b</a></body></html>`)
+			return
+		}
+		//each link body is streamed in chunks until the client stops reading
+		for i := 0; i < 100; i++ {
+			n, err := fmt.Fprint(w, strings.Repeat("x", 1000))
+			atomic.AddInt64(&served, int64(n))
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+	defer func() { totalBytes = nil }()
+
+	//the link bodies read to time them exceed the cap, the page alone fits
+	for _, args := range [][]string{{"--ttfb"}, {"--ttfb", "--timeout-retry-on-slow", "1ns"}} {
+		var stdout, stderr bytes.Buffer
+		code := run(append(args, "--workers", "1", "--max-total-bytes", "5000", ts.URL+"/"), &stdout, &stderr)
+		if code != exitMaxTotalBytes {
+			t.Fatalf("%v: expected exit code %d, got %d: %s", args, exitMaxTotalBytes, code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "max total bytes of 5000 reached") {
+			t.Errorf("%v: expected the cap to be reported, got %q", args, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "Page timing:") {
+			t.Errorf("%v: expected the report, got\n%s", args, stdout.String())
+		}
+	}
+	if n := atomic.LoadInt64(&served); n >= 100000 {
+		t.Errorf("expected the link bodies to stop downloading at the cap, %d bytes were served", n)
+	}
+}
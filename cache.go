@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheInfo is the interpretation of the Cache-Control, Expires and Age headers of a response
+type cacheInfo struct {
+	maxAge    int //seconds, -1 if neither max-age nor Expires is set
+	age       int
+	noStore   bool
+	noCache   bool
+	private   bool
+	public    bool
+	immutable bool
+	//warnings explain contradictory directives and stale or immutable html
+	warnings []string
+}
+
+func (c cacheInfo) String() string {
+	switch {
+	case c.noStore:
+		return "no-store"
+	case c.noCache:
+		return "no-cache"
+	case c.maxAge < 0:
+		return "no freshness information"
+	}
+	scope := "shared"
+	if c.private {
+		scope = "private"
+	}
+	if c.age > c.maxAge {
+		return fmt.Sprintf("%s, stale (age %ds exceeds max-age %ds)", scope, c.age, c.maxAge)
+	}
+	return fmt.Sprintf("%s, fresh for %ds (age %ds)", scope, c.maxAge-c.age, c.age)
+}
+
+// parseCacheHeaders interprets the cache headers of a HTML response
+func parseCacheHeaders(header http.Header) cacheInfo {
+	c := cacheInfo{maxAge: -1}
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			parts := strings.SplitN(strings.TrimSpace(d), "=", 2)
+			name := strings.ToLower(parts[0])
+			switch name {
+			case "max-age":
+				if len(parts) == 2 {
+					if n, err := strconv.Atoi(strings.Trim(parts[1], `"`)); err == nil {
+						c.maxAge = n
+					}
+				}
+			case "no-store":
+				c.noStore = true
+			case "no-cache":
+				c.noCache = true
+			case "private":
+				c.private = true
+			case "public":
+				c.public = true
+			case "immutable":
+				c.immutable = true
+			}
+		}
+	}
+	if n, err := strconv.Atoi(header.Get("Age")); err == nil {
+		c.age = n
+	}
+
+	//max-age wins over Expires
+	if expires := header.Get("Expires"); expires != "" && c.maxAge < 0 {
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		if t, err := http.ParseTime(expires); err == nil {
+			c.maxAge = int(t.Sub(date).Seconds())
+		} else {
+			//invalid Expires values like "0" mean already expired
+			c.maxAge = 0
+		}
+		if c.maxAge < 0 {
+			c.maxAge = 0
+		}
+	}
+
+	if c.immutable {
+		c.warnings = append(c.warnings, "HTML page is marked immutable")
+	}
+	if c.public && c.private {
+		c.warnings = append(c.warnings, "Cache-Control is both public and private")
+	}
+	if c.noStore && c.maxAge > 0 {
+		c.warnings = append(c.warnings, "Cache-Control no-store contradicts a max-age > 0")
+	}
+	if c.maxAge >= 0 && c.age > c.maxAge && !c.noStore {
+		c.warnings = append(c.warnings, fmt.Sprintf("response is stale: age %ds exceeds max-age %ds", c.age, c.maxAge))
+	}
+	return c
+}
+
+// conditionalGet is the outcome of requesting a page again with the validators of its first response
+type conditionalGet struct {
+	checked bool
+	//validators are the If-None-Match and If-Modified-Since headers sent, none if the page has neither ETag nor Last-Modified
+	validators []string
+	status     int
+	err        error
+}
+
+// supported returns true if the server answered the conditional request with 304 Not Modified
+func (c conditionalGet) supported() bool {
+	return c.status == http.StatusNotModified
+}
+
+// ignored returns true if the server sent the full page again although the validators are unchanged
+func (c conditionalGet) ignored() bool {
+	return len(c.validators) > 0 && c.status == http.StatusOK
+}
+
+func (c conditionalGet) String() string {
+	switch {
+	case len(c.validators) == 0:
+		return "not possible, the page has no ETag or Last-Modified"
+	case c.err != nil:
+		return fmt.Sprintf("failed: %v", c.err)
+	case c.supported():
+		return "supported, 304 Not Modified"
+	case c.ignored():
+		return "validators ignored, 200 OK"
+	}
+	return fmt.Sprintf("unexpected status %d", c.status)
+}
+
+// checkConditionalGet requests the page again with the ETag and Last-Modified of fr
+// it is not an analyzer, as it doubles the requests, run calls it for the seed with --conditional-get
+func checkConditionalGet(ctx context.Context, fr *fetchResult) conditionalGet {
+	c := conditionalGet{checked: true}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fr.url, nil)
+	if err != nil {
+		c.err = err
+		return c
+	}
+	if fr.etag != "" {
+		req.Header.Set("If-None-Match", fr.etag)
+		c.validators = append(c.validators, "If-None-Match")
+	}
+	if fr.lastModified != "" {
+		req.Header.Set("If-Modified-Since", fr.lastModified)
+		c.validators = append(c.validators, "If-Modified-Since")
+	}
+	if len(c.validators) == 0 {
+		return c
+	}
+	res, err := doRequest(ctx, req)
+	if err != nil {
+		c.err = err
+		return c
+	}
+	res.Body.Close()
+	c.status = res.StatusCode
+	return c
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCacheHeaders(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    cacheInfo
+		text    string
+	}{
+		{"none", nil, cacheInfo{maxAge: -1}, "no freshness information"},
+		{"max-age", map[string]string{"Cache-Control": "public, max-age=600"}, cacheInfo{maxAge: 600, public: true}, "shared, fresh for 600s (age 0s)"},
+		{"no-store", map[string]string{"Cache-Control": "no-store"}, cacheInfo{maxAge: -1, noStore: true}, "no-store"},
+		{"no-cache private", map[string]string{"Cache-Control": "private, no-cache"}, cacheInfo{maxAge: -1, noCache: true, private: true}, "no-cache"},
+		{"expires", map[string]string{"Expires": expires, "Date": time.Now().UTC().Format(http.TimeFormat)}, cacheInfo{maxAge: 3600}, "shared, fresh for 3600s (age 0s)"},
+		{"immutable", map[string]string{"Cache-Control": "max-age=31536000, immutable"}, cacheInfo{
+			maxAge: 31536000, immutable: true, warnings: []string{"HTML page is marked immutable"},
+		}, "shared, fresh for 31536000s (age 0s)"},
+		{"contradictory", map[string]string{"Cache-Control": "public, private, no-store, max-age=60"}, cacheInfo{
+			maxAge: 60, noStore: true, public: true, private: true,
+			warnings: []string{"Cache-Control is both public and private", "Cache-Control no-store contradicts a max-age > 0"},
+		}, "no-store"},
+		{"stale", map[string]string{"Cache-Control": "max-age=60", "Age": "120"}, cacheInfo{
+			maxAge: 60, age: 120, warnings: []string{"response is stale: age 120s exceeds max-age 60s"},
+		}, "shared, stale (age 120s exceeds max-age 60s)"},
+	}
+
+	handlers := http.NewServeMux()
+	for _, tt := range tests {
+		headers := tt.headers
+		handlers.HandleFunc("/"+tt.name, func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			http.ServeFile(w, r, "testdata/plain.html")
+		})
+	}
+	ts := httptest.NewServer(handlers)
+	defer ts.Close()
+
+	for _, tt := range tests {
+		_, res, err := parsePage(context.Background(), ts.URL+"/"+tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := parseCacheHeaders(res.Header)
+		//the Expires test may take a moment to run
+		if tt.name == "expires" && got.maxAge >= 3598 && got.maxAge <= 3600 {
+			got.maxAge = 3600
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+		if got.String() != tt.text {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.text, got.String())
+		}
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	page, err := ioutil.ReadFile("testdata/plain.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := http.NewServeMux()
+	//http.ServeFile compares If-None-Match and If-Modified-Since and answers 304
+	handlers.HandleFunc("/honors", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	handlers.HandleFunc("/ignores", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(page)
+	})
+	handlers.HandleFunc("/none", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(page)
+	})
+	ts := httptest.NewServer(handlers)
+	defer ts.Close()
+
+	tests := []struct {
+		path       string
+		validators []string
+		status     int
+		ignored    bool
+	}{
+		{"/honors", []string{"If-None-Match", "If-Modified-Since"}, http.StatusNotModified, false},
+		{"/ignores", []string{"If-None-Match"}, http.StatusOK, true},
+		{"/none", nil, 0, false},
+	}
+	for _, tt := range tests {
+		fr, err := analyzePage(context.Background(), ts.URL+tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr.conditionalGet = checkConditionalGet(context.Background(), fr)
+		c := fr.conditionalGet
+		if !c.checked || c.err != nil || !reflect.DeepEqual(c.validators, tt.validators) || c.status != tt.status {
+			t.Errorf("%s: expected validators %v and status %d, got %+v", tt.path, tt.validators, tt.status, c)
+		}
+		if c.supported() != (tt.status == http.StatusNotModified) {
+			t.Errorf("%s: expected supported %t", tt.path, !c.supported())
+		}
+		if hasFinding(fr, "ConditionalGetIgnored") != tt.ignored {
+			t.Errorf("%s: expected ConditionalGetIgnored finding %t", tt.path, tt.ignored)
+		}
+	}
+}
+
+func TestConditionalGetOptions(t *testing.T) {
+	var stderr bytes.Buffer
+	//without the cache analyzer the page has no validators to send
+	for _, args := range [][]string{
+		{"--conditional-get", "--checks", "meta"},
+		{"--conditional-get", "--profile", "a11y"},
+		{"--conditional-get", "--streaming"},
+	} {
+		if _, err := parseOptions(append(args, "http://example.com/"), &stderr); err == nil {
+			t.Errorf("%v: expected an error without the cache check", args)
+		}
+	}
+	for _, args := range [][]string{{"--conditional-get"}, {"--conditional-get", "--checks", "meta,cache"}} {
+		if _, err := parseOptions(append(args, "http://example.com/"), &stderr); err != nil {
+			t.Errorf("%v: expected the options to be valid, got %v", args, err)
+		}
+	}
+}
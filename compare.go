@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// comparePages analyzes both urls and returns the differences of the second to the first
+func comparePages(ctx context.Context, first, second string) ([]string, error) {
+	a, err := analyzePage(ctx, first)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", first, err)
+	}
+	b, err := analyzePage(ctx, second)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", second, err)
+	}
+	return diffResults(a, b), nil
+}
+
+// diffResults compares title, version, headings, links and warnings of two pages
+// lines starting with - are only in a, lines starting with + only in b
+func diffResults(a, b *fetchResult) []string {
+	diffs := []string{}
+	if a.title != b.title {
+		diffs = append(diffs, fmt.Sprintf("title: %q -> %q", a.title, b.title))
+	}
+	if a.version != b.version {
+		diffs = append(diffs, fmt.Sprintf("HTML version: %q -> %q", a.version, b.version))
+	}
+	for i := 1; i <= 6; i++ {
+		h := fmt.Sprintf("h%d", i)
+		if a.headings[h] != b.headings[h] {
+			diffs = append(diffs, fmt.Sprintf("%s count: %d -> %d", h, a.headings[h], b.headings[h]))
+		}
+	}
+	diffs = append(diffs, diffSets("link", a.urls, b.urls)...)
+
+	kinds := func(fr *fetchResult) []string {
+		k := []string{}
+		for _, f := range fr.findings() {
+			k = append(k, f.kind)
+		}
+		return k
+	}
+	diffs = append(diffs, diffSets("warning", kinds(a), kinds(b))...)
+	return diffs
+}
+
+// diffSets returns sorted "- name value" lines for values only in a and "+ name value" lines for values only in b
+func diffSets(name string, a, b []string) []string {
+	inA, inB := map[string]bool{}, map[string]bool{}
+	for _, v := range a {
+		inA[v] = true
+	}
+	for _, v := range b {
+		inB[v] = true
+	}
+	removed, added := []string{}, []string{}
+	for v := range inA {
+		if !inB[v] {
+			removed = append(removed, "- "+name+" "+v)
+		}
+	}
+	for v := range inB {
+		if !inA[v] {
+			added = append(added, "+ "+name+" "+v)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	return append(removed, added...)
+}
+
+// displayDiffs prints the differences between two pages
+func displayDiffs(w io.Writer, first, second string, diffs []string) {
+	fmt.Fprintf(w, "Comparing %s with %s\n", first, second)
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "no differences")
+		return
+	}
+	for _, d := range diffs {
+		fmt.Fprintln(w, d)
+	}
+}
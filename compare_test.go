@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/production": "testdata/compare_a.html",
+		"/staging":    "testdata/compare_b.html",
+	})
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--compare", ts.URL + "/staging", ts.URL + "/production"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	want := "Comparing " + ts.URL + "/production with " + ts.URL + "/staging\n" +
+		"title: \"Production\" -> \"Staging\"\n" +
+		"h1 count: 1 -> 2\n" +
+		"- link /contact\n" +
+		"+ link /pricing\n" +
+		"+ warning MultipleH1\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("expected output\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestDiffResultsEqual(t *testing.T) {
+	a := fetch(loadFixture(t, "compare_a.html"))
+	b := fetch(loadFixture(t, "compare_a.html"))
+	if diffs := diffResults(a, b); !reflect.DeepEqual(diffs, []string{}) {
+		t.Fatalf("expected no differences, got %v", diffs)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// minContrast is the contrast ratio below which an inline color pair is reported
+// it is the WCAG minimum for large text, so only obviously unreadable pairs are flagged
+const minContrast = 3.0
+
+// namedColors are the CSS color keywords recognized by parseColor
+var namedColors = map[string][3]uint8{
+	"black":  {0, 0, 0},
+	"white":  {255, 255, 255},
+	"gray":   {128, 128, 128},
+	"grey":   {128, 128, 128},
+	"silver": {192, 192, 192},
+	"red":    {255, 0, 0},
+	"maroon": {128, 0, 0},
+	"yellow": {255, 255, 0},
+	"lime":   {0, 255, 0},
+	"green":  {0, 128, 0},
+	"blue":   {0, 0, 255},
+	"navy":   {0, 0, 128},
+}
+
+// parseColor parses #rgb, #rrggbb, rgb() and rgba() values and the namedColors
+func parseColor(v string) ([3]uint8, bool) {
+	v = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important")))
+	if c, ok := namedColors[v]; ok {
+		return c, true
+	}
+	var c [3]uint8
+	if strings.HasPrefix(v, "#") {
+		hex := v[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return c, false
+		}
+		for i := 0; i < 3; i++ {
+			n, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+			if err != nil {
+				return c, false
+			}
+			c[i] = uint8(n)
+		}
+		return c, true
+	}
+	if strings.HasPrefix(v, "rgb(") || strings.HasPrefix(v, "rgba(") {
+		args := strings.Split(strings.TrimSuffix(v[strings.Index(v, "(")+1:], ")"), ",")
+		if len(args) < 3 {
+			return c, false
+		}
+		for i := 0; i < 3; i++ {
+			n, err := strconv.Atoi(strings.TrimSpace(args[i]))
+			if err != nil || n < 0 || n > 255 {
+				return c, false
+			}
+			c[i] = uint8(n)
+		}
+		return c, true
+	}
+	return c, false
+}
+
+// luminance is the WCAG relative luminance of a color
+func luminance(c [3]uint8) float64 {
+	l := [3]float64{}
+	for i, v := range c {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			l[i] = s / 12.92
+		} else {
+			l[i] = math.Pow((s+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2]
+}
+
+// contrastRatio is the WCAG contrast ratio of two colors, from 1 to 21
+func contrastRatio(a, b [3]uint8) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// getLowContrast lists the elements whose style attribute sets both color and background(-color) with a contrast below minContrast
+// colors set by stylesheets or inherited are not considered, this needs rendering
+func getLowContrast(doc *goquery.Document) []string {
+	low := []string{}
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		var fg, bg string
+		for _, decl := range strings.Split(s.AttrOr("style", ""), ";") {
+			parts := strings.SplitN(decl, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(parts[0])) {
+			case "color":
+				fg = parts[1]
+			case "background-color", "background":
+				bg = parts[1]
+			}
+		}
+		f, ok := parseColor(fg)
+		if !ok {
+			return
+		}
+		b, ok := parseColor(bg)
+		if !ok {
+			return
+		}
+		if r := contrastRatio(f, b); r < minContrast {
+			low = append(low, fmt.Sprintf("%s color %s on %s (%.1f:1)", goquery.NodeName(s), strings.TrimSpace(fg), strings.TrimSpace(bg), r))
+		}
+	})
+	return low
+}
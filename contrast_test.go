@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLowContrast(t *testing.T) {
+	fr := fetch(loadFixture(t, "low_contrast.html"))
+	if want := []string{"p color #777 on #888 (1.3:1)"}; !reflect.DeepEqual(fr.lowContrast, want) {
+		t.Errorf("expected %v, got %v", want, fr.lowContrast)
+	}
+	if !hasFinding(fr, "ContrastWarnings") {
+		t.Errorf("expected a ContrastWarnings finding, got %v", fr.findings())
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black, _ := parseColor("#000")
+	white, _ := parseColor("rgb(255, 255, 255)")
+	if r := contrastRatio(black, white); r < 20.9 || r > 21.1 {
+		t.Errorf("expected black on white to be 21:1, got %.2f", r)
+	}
+	if r := contrastRatio(white, white); r != 1 {
+		t.Errorf("expected equal colors to be 1:1, got %.2f", r)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// controller pauses and resumes the dispatch of new pages and links, requests in flight are not interrupted
+// it also tracks the progress reported by GET /status, a nil controller never pauses
+type controller struct {
+	mu     sync.Mutex
+	paused bool
+	//resumed is closed on resume, waiting dispatchers block on it while paused
+	resumed chan struct{}
+	status  controlStatus
+}
+
+// controlStatus is the response of GET /status
+type controlStatus struct {
+	Paused       bool `json:"paused"`
+	PagesVisited int  `json:"pagesVisited"`
+	PagesQueued  int  `json:"pagesQueued"`
+	LinksChecked int  `json:"linksChecked"`
+}
+
+func (c *controller) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+	}
+}
+
+func (c *controller) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		close(c.resumed)
+	}
+}
+
+// wait blocks while paused, it returns the error of ctx if ctx is done first
+func (c *controller) wait(ctx context.Context) error {
+	if c == nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	paused, resumed := c.paused, c.resumed
+	c.mu.Unlock()
+	if !paused {
+		return ctx.Err()
+	}
+	select {
+	case <-resumed:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pageProgress records the pages visited and queued by the crawler
+func (c *controller) pageProgress(visited, queued int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.status.PagesVisited, c.status.PagesQueued = visited, queued
+	c.mu.Unlock()
+}
+
+// linkChecked counts a checked link
+func (c *controller) linkChecked() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.status.LinksChecked++
+	c.mu.Unlock()
+}
+
+func (c *controller) currentStatus() controlStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.status
+	s.Paused = c.paused
+	return s
+}
+
+// handler serves POST /pause, POST /resume and GET /status, all of them respond with the status
+func (c *controller) handler() http.Handler {
+	mux := http.NewServeMux()
+	respond := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.currentStatus())
+	}
+	post := func(action func()) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			action()
+			respond(w)
+		}
+	}
+	mux.Handle("/pause", post(c.pause))
+	mux.Handle("/resume", post(c.resume))
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		respond(w)
+	})
+	return mux
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// controlStatusOf calls an endpoint of the control plane and decodes the status
+func controlStatusOf(t *testing.T, method, url string) controlStatus {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var s controlStatus
+	if err := json.NewDecoder(res.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPauseResume(t *testing.T) {
+	var hits int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			close(started)
+			<-release
+		}
+	}))
+	defer ts.Close()
+
+	control := &controller{}
+	cp := httptest.NewServer(control.handler())
+	defer cp.Close()
+
+	links := []string{ts.URL + "/1", ts.URL + "/2", ts.URL + "/3"}
+	checker := &linkChecker{workers: 1, control: control}
+	done := make(chan []linkResult)
+	go func() { done <- checker.check(context.Background(), links) }()
+
+	//pause while the first link is in flight, it still completes
+	<-started
+	if s := controlStatusOf(t, http.MethodPost, cp.URL+"/pause"); !s.Paused {
+		t.Fatalf("expected the status to be paused, got %+v", s)
+	}
+	close(release)
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("expected no links to be dispatched while paused, got %d requests", n)
+	}
+	if s := controlStatusOf(t, http.MethodGet, cp.URL+"/status"); !s.Paused || s.LinksChecked != 1 {
+		t.Errorf("expected 1 checked link while paused, got %+v", s)
+	}
+
+	controlStatusOf(t, http.MethodPost, cp.URL+"/resume")
+	select {
+	case results := <-done:
+		if countStatus(results, linkOK) != 3 {
+			t.Errorf("expected all links to be checked after resume, got %v", results)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the check to finish after resume")
+	}
+	if s := controlStatusOf(t, http.MethodGet, cp.URL+"/status"); s.Paused || s.LinksChecked != 3 {
+		t.Errorf("expected 3 checked links after resume, got %+v", s)
+	}
+}
+
+func TestPausedCrawlStopsAtDeadline(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	control := &controller{}
+	control.pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3, control: control}).crawl(ctx, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 0 {
+		t.Errorf("expected no pages to be visited while paused, got %d", len(pages))
+	}
+	if s := control.currentStatus(); s.PagesQueued != 1 {
+		t.Errorf("expected the seed to stay queued, got %+v", s)
+	}
+}
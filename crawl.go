@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// crawlPage is a page visited by the crawler
+type crawlPage struct {
+	url    string
+	depth  int
+	status int
+	err    error
+	result *fetchResult
+	//links are the normalized internal links found on the page
+	links []string
+	//textHash and simhash are computed from the normalized visible text, see duplicates.go
+	textHash string
+	simhash  uint64
+	//next is the normalized rel=next url of the page, if it is on the same host
+	next string
+}
+
+// crawler visits the internal pages reachable from a seed, breadth first
+type crawler struct {
+	maxPages int
+	maxDepth int
+	filter   *urlFilter
+	//followPagination also enqueues the rel=next and rel=prev pages, which are not linked with anchors
+	followPagination bool
+	//control pauses the crawl between pages if not nil
+	control *controller
+	//events receives PageStarted and PageFinished events if not nil
+	events chan<- event
+}
+
+// crawl visits seed and the pages on the same host it links to, within maxPages and maxDepth
+// it stops when ctx is done, returning the pages visited so far
+func (c *crawler) crawl(ctx context.Context, seed string) ([]*crawlPage, error) {
+	start, err := url.Parse(seed)
+	if err != nil {
+		return nil, err
+	}
+	first := normalizeURL(start)
+	//visited maps the crawlKey of every discovered url to the url of its page, the first form it was found in
+	visited := map[string]string{crawlKey(start): first}
+	queue := []*crawlPage{{url: first}}
+	pages := []*crawlPage{}
+
+	for len(queue) > 0 && len(pages) < c.maxPages && ctx.Err() == nil {
+		c.control.pageProgress(len(pages), len(queue))
+		if c.control.wait(ctx) != nil {
+			break
+		}
+		p := queue[0]
+		queue = queue[1:]
+		pages = append(pages, p)
+
+		emit(c.events, event{Type: eventPageStarted, URL: p.url})
+		doc, res, err := parsePage(ctx, p.url)
+		if res != nil {
+			p.status = res.StatusCode
+		}
+		finished := event{Type: eventPageFinished, URL: p.url, Status: p.status}
+		if err != nil {
+			p.err = err
+			finished.Error = err.Error()
+			emit(c.events, finished)
+			continue
+		}
+		emit(c.events, finished)
+		p.result = analyzeDocument(ctx, doc, res)
+		p.textHash, p.simhash = hashText(visibleText(doc))
+
+		base := res.Request.URL
+		if b, err := url.Parse(p.result.linkBase()); err == nil {
+			base = b
+		}
+		//internal returns the url of the page a non-empty link points to if it is on the crawled host
+		//it is the url the page was first discovered with, or the normalized link for a new page
+		internal := func(link string) (string, bool) {
+			if strings.TrimSpace(link) == "" {
+				return "", false
+			}
+			u, err := base.Parse(strings.TrimSpace(link))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.EqualFold(u.Host, start.Host) {
+				return "", false
+			}
+			if n, ok := visited[crawlKey(u)]; ok {
+				return n, true
+			}
+			return normalizeURL(u), true
+		}
+		enqueue := func(n string) {
+			u, _ := url.Parse(n)
+			key := crawlKey(u)
+			if _, ok := visited[key]; ok || !c.filter.allowed(n) || p.depth >= c.maxDepth {
+				return
+			}
+			visited[key] = n
+			queue = append(queue, &crawlPage{url: n, depth: p.depth + 1})
+		}
+
+		if n, ok := internal(p.result.paginationNext); ok {
+			p.next = n
+		}
+		if c.followPagination {
+			for _, link := range []string{p.result.paginationNext, p.result.paginationPrev} {
+				if n, ok := internal(link); ok {
+					enqueue(n)
+				}
+			}
+		}
+		for _, link := range p.result.urls {
+			n, ok := internal(link)
+			if !ok {
+				continue
+			}
+			if n != p.url && !contains(p.links, n) {
+				p.links = append(p.links, n)
+			}
+			enqueue(n)
+		}
+	}
+	c.control.pageProgress(len(pages), len(queue))
+	return pages, nil
+}
+
+// crawlDiff contains the urls which were added and removed since a previous crawl, see --previous-crawl
+type crawlDiff struct {
+	//added are in crawl order, removed in the order of the previous crawl
+	added   []string
+	removed []string
+}
+
+// diffCrawls compares the urls of the crawled pages with the urls of a previous crawl
+func diffCrawls(previous []string, pages []*crawlPage) *crawlDiff {
+	d := &crawlDiff{added: []string{}, removed: []string{}}
+	before := map[string]bool{}
+	for _, u := range previous {
+		before[u] = true
+	}
+	now := map[string]bool{}
+	for _, p := range pages {
+		now[p.url] = true
+		if !before[p.url] {
+			d.added = append(d.added, p.url)
+		}
+	}
+	for _, u := range previous {
+		if !now[u] {
+			d.removed = append(d.removed, u)
+		}
+	}
+	return d
+}
+
+// writeCrawlDiff prints the added and removed urls, up to maxExamples each
+func writeCrawlDiff(w io.Writer, d *crawlDiff, maxExamples int) {
+	fmt.Fprintf(w, "Added urls since the previous crawl: %d\n", len(d.added))
+	writeExamples(w, "  ", d.added, maxExamples)
+	fmt.Fprintf(w, "Removed urls since the previous crawl: %d\n", len(d.removed))
+	writeExamples(w, "  ", d.removed, maxExamples)
+}
+
+// paginationChains follows the rel=next urls of the crawled pages and returns each chain from its first page
+// a chain ends at a page which was not crawled or which was already part of the chain
+func paginationChains(pages []*crawlPage) [][]string {
+	byURL := map[string]*crawlPage{}
+	targets := map[string]bool{}
+	for _, p := range pages {
+		byURL[p.url] = p
+		if p.next != "" {
+			targets[p.next] = true
+		}
+	}
+	chains := [][]string{}
+	for _, p := range pages {
+		if p.next == "" || targets[p.url] {
+			continue
+		}
+		chain := []string{p.url}
+		seen := map[string]bool{p.url: true}
+		for n := p.next; n != "" && !seen[n]; {
+			chain = append(chain, n)
+			seen[n] = true
+			t, ok := byURL[n]
+			if !ok {
+				break
+			}
+			n = t.next
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// brokenLink is an internal link to a crawled page which did not return a 2xx status
+type brokenLink struct {
+	source string
+	target string
+	status int
+	err    error
+}
+
+// brokenInternalLinks cross-references the links of every page with the crawled pages
+// links to pages which were not crawled, e.g. because of --max-pages, are not reported
+func brokenInternalLinks(pages []*crawlPage) []brokenLink {
+	byURL := map[string]*crawlPage{}
+	for _, p := range pages {
+		byURL[p.url] = p
+	}
+	broken := []brokenLink{}
+	for _, p := range pages {
+		for _, l := range p.links {
+			t, ok := byURL[l]
+			if !ok || (t.status >= 200 && t.status < 300) {
+				continue
+			}
+			broken = append(broken, brokenLink{source: p.url, target: l, status: t.status, err: t.err})
+		}
+	}
+	return broken
+}
+
+// brokenTarget is a broken internal link target with the pages linking to it
+type brokenTarget struct {
+	target string
+	status int
+	err    error
+	//sources are in crawl order
+	sources []string
+}
+
+// brokenTargets attributes the broken links to their targets, in order of first appearance
+func brokenTargets(broken []brokenLink) []*brokenTarget {
+	targets := []*brokenTarget{}
+	byTarget := map[string]*brokenTarget{}
+	for _, b := range broken {
+		t, ok := byTarget[b.target]
+		if !ok {
+			t = &brokenTarget{target: b.target, status: b.status, err: b.err}
+			byTarget[b.target] = t
+			targets = append(targets, t)
+		}
+		t.sources = append(t.sources, b.source)
+	}
+	return targets
+}
+
+// brokenPage is a crawled page with its broken outbound internal links
+type brokenPage struct {
+	source string
+	links  []brokenLink
+}
+
+// brokenLinksByPage groups the broken links by the page containing them, in crawl order
+func brokenLinksByPage(broken []brokenLink) []*brokenPage {
+	pages := []*brokenPage{}
+	for _, b := range broken {
+		//brokenInternalLinks returns the links of a page consecutively
+		if len(pages) == 0 || pages[len(pages)-1].source != b.source {
+			pages = append(pages, &brokenPage{source: b.source})
+		}
+		p := pages[len(pages)-1]
+		p.links = append(p.links, b)
+	}
+	return pages
+}
+
+// collapseTrailingSlash makes /path/ and /path the same page for crawlKey, run sets it from --normalize-trailing-slash
+// by default they are different pages, as servers may answer them differently
+var collapseTrailingSlash bool
+
+// trimTrailingSlash removes a trailing slash of path if collapseTrailingSlash is set, the root path keeps it
+func trimTrailingSlash(path string) string {
+	if collapseTrailingSlash && len(path) > 1 {
+		return strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// normalizeURL returns u without fragment, default port and with lower case scheme and host
+// it is used to detect already visited pages
+func normalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if (n.Scheme == "http" && strings.HasSuffix(n.Host, ":80")) || (n.Scheme == "https" && strings.HasSuffix(n.Host, ":443")) {
+		n.Host = n.Host[:strings.LastIndex(n.Host, ":")]
+	}
+	n.Fragment = ""
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return n.String()
+}
+
+// crawlKey returns the key of u in the visited set of a crawl, its normalizeURL without trailing slash if collapseTrailingSlash is set
+// the page itself is still requested with the url it was discovered with, so its relative links resolve correctly
+func crawlKey(u *url.URL) string {
+	n := *u
+	n.Path, n.RawPath = trimTrailingSlash(n.Path), trimTrailingSlash(n.RawPath)
+	return normalizeURL(&n)
+}
+
+// findingGroup is a finding kind aggregated across the crawled pages by --dedupe-output
+type findingGroup struct {
+	kind string
+	//message is the message of the first page with the finding
+	message string
+	urls    []string
+}
+
+// dedupeSample is the number of affected urls printed per findingGroup
+const dedupeSample = 5
+
+// groupFindings aggregates the findings of pages by kind, in order of first appearance
+// a page is listed once per kind even if it has several findings of that kind
+func groupFindings(pages []*crawlPage) []*findingGroup {
+	groups := []*findingGroup{}
+	byKind := map[string]*findingGroup{}
+	for _, p := range pages {
+		if p.result == nil {
+			continue
+		}
+		for _, f := range p.result.findings() {
+			g, ok := byKind[f.kind]
+			if !ok {
+				g = &findingGroup{kind: f.kind, message: f.message}
+				byKind[f.kind] = g
+				groups = append(groups, g)
+			}
+			if len(g.urls) == 0 || g.urls[len(g.urls)-1] != p.url {
+				g.urls = append(g.urls, p.url)
+			}
+		}
+	}
+	return groups
+}
+
+// displayCrawl prints the visited pages and groups of duplicate pages
+// with dedupe the findings are printed once per kind with a sample of the affected pages instead of per page
+func displayCrawl(w io.Writer, pages []*crawlPage, maxExamples int, dedupe bool) {
+	fmt.Fprintf(w, "Crawled %d pages:\n", len(pages))
+	for _, p := range pages {
+		if p.err != nil {
+			fmt.Fprintf(w, "%s - %v\n", p.url, p.err)
+			continue
+		}
+		fmt.Fprintf(w, "%s - %s\n", p.url, p.result.title)
+		if dedupe {
+			continue
+		}
+		for _, f := range p.result.findings() {
+			fmt.Fprintf(w, "  Warning: %s\n", f.message)
+			writeExamples(w, "    ", f.examples, maxExamples)
+		}
+	}
+	if dedupe {
+		sample := dedupeSample
+		if maxExamples > 0 && maxExamples < sample {
+			sample = maxExamples
+		}
+		for _, g := range groupFindings(pages) {
+			fmt.Fprintf(w, "Warning %s on %d pages, e.g. %s\n", g.kind, len(g.urls), g.message)
+			writeExamples(w, "  ", g.urls, sample)
+		}
+	}
+
+	broken := brokenInternalLinks(pages)
+	linkedFrom := map[string]int{}
+	for _, t := range brokenTargets(broken) {
+		linkedFrom[t.target] = len(t.sources)
+	}
+	for _, p := range brokenLinksByPage(broken) {
+		fmt.Fprintf(w, "Broken internal links on %s: %d\n", p.source, len(p.links))
+		for _, b := range p.links {
+			reason := fmt.Sprint(b.status)
+			if b.status == 0 {
+				reason = fmt.Sprint(b.err)
+			}
+			fmt.Fprintf(w, "  - %s (%s), linked from %d pages\n", b.target, reason, linkedFrom[b.target])
+		}
+	}
+
+	for _, c := range paginationChains(pages) {
+		fmt.Fprintf(w, "Pagination chain: %s\n", strings.Join(c, " -> "))
+	}
+
+	duplicates, nearDuplicates := duplicateGroups(pages)
+	for _, g := range duplicates {
+		fmt.Fprintf(w, "Duplicate content: %s\n", strings.Join(g, ", "))
+	}
+	for _, g := range nearDuplicates {
+		fmt.Fprintf(w, "Near-duplicate content: %s\n", strings.Join(g, ", "))
+	}
+}
+
+// writeEdgesCSV writes the internal link graph as source,target rows, one per distinct edge
+func writeEdgesCSV(w io.Writer, pages []*crawlPage) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "target"})
+	for _, p := range pages {
+		//p.links contains every target once
+		for _, l := range p.links {
+			cw.Write([]string{p.url, l})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMermaid writes the internal link graph as a Mermaid flowchart, nodes have short ids labeled with their url
+// pages get ids in crawl order, linked urls which were not crawled after them
+func writeMermaid(w io.Writer, pages []*crawlPage) error {
+	ids := map[string]string{}
+	nodes := []string{}
+	id := func(u string) string {
+		if _, ok := ids[u]; !ok {
+			ids[u] = fmt.Sprintf("n%d", len(nodes))
+			nodes = append(nodes, u)
+		}
+		return ids[u]
+	}
+	for _, p := range pages {
+		id(p.url)
+	}
+	edges := []string{}
+	seen := map[string]bool{}
+	for _, p := range pages {
+		for _, l := range p.links {
+			e := id(p.url) + " --> " + id(l)
+			if !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph LR")
+	for _, u := range nodes {
+		//quotes would end the label, mermaid reads #quot; as one
+		fmt.Fprintf(bw, "  %s[\"%s\"]\n", ids[u], strings.ReplaceAll(u, `"`, "#quot;"))
+	}
+	for _, e := range edges {
+		fmt.Fprintf(bw, "  %s\n", e)
+	}
+	return bw.Flush()
+}
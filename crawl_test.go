@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// siteServer serves the pages in testdata/site
+func siteServer() *httptest.Server {
+	return fixtureServer(map[string]string{
+		"/":              "testdata/site/index.html",
+		"/article":       "testdata/site/article.html",
+		"/article/print": "testdata/site/article_print.html",
+		"/other":         "testdata/site/other.html",
+	})
+}
+
+// fixtureServer serves a fixture file for each path, other paths are not found
+func fixtureServer(pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, f)
+	}))
+}
+
+func TestCrawl(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, p := range pages {
+		got = append(got, p.url)
+	}
+	want := []string{ts.URL + "/", ts.URL + "/article", ts.URL + "/article/print", ts.URL + "/other"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected pages %v, got %v", want, got)
+	}
+
+	pages, err = (&crawler{maxPages: 2, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("expected max pages to limit the crawl to 2 pages, got %d", len(pages))
+	}
+}
+
+func TestDuplicatePages(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicates, _ := duplicateGroups(pages)
+	want := [][]string{{ts.URL + "/article", ts.URL + "/article/print"}}
+	if !reflect.DeepEqual(duplicates, want) {
+		t.Fatalf("expected duplicates %v, got %v", want, duplicates)
+	}
+}
+
+func TestCrawlIncludePattern(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":                  "testdata/blog/index.html",
+		"/about":             "testdata/plain.html",
+		"/contact":           "testdata/plain.html",
+		"/blog/first":        "testdata/blog/post.html",
+		"/blog/second":       "testdata/blog/post.html",
+		"/blog/drafts/third": "testdata/blog/post.html",
+	})
+	defer ts.Close()
+
+	filter := &urlFilter{}
+	if err := filter.include.Set("/blog/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.ignore.Set("/drafts/"); err != nil {
+		t.Fatal(err)
+	}
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3, filter: filter}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, p := range pages {
+		got = append(got, p.url)
+	}
+	//the seed is always visited, the ignore pattern wins over the include pattern
+	want := []string{ts.URL + "/", ts.URL + "/blog/first", ts.URL + "/blog/second"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected pages %v, got %v", want, got)
+	}
+}
+
+func TestBrokenInternalLinks(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":   "testdata/broken/index.html",
+		"/ok": "testdata/broken/ok.html",
+	})
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := brokenInternalLinks(pages)
+	if len(broken) != 2 {
+		t.Fatalf("expected 2 broken internal links, got %+v", broken)
+	}
+	for i, source := range []string{ts.URL + "/", ts.URL + "/ok"} {
+		b := broken[i]
+		if b.source != source || b.target != ts.URL+"/missing" || b.status != http.StatusNotFound {
+			t.Errorf("expected %s -> /missing (404), got %+v", source, b)
+		}
+	}
+}
+
+func TestBrokenLinkAttribution(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":   "testdata/broken/index.html",
+		"/ok": "testdata/broken/ok.html",
+	})
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := brokenInternalLinks(pages)
+	targets := brokenTargets(broken)
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 broken target, got %+v", targets)
+	}
+	sources := []string{ts.URL + "/", ts.URL + "/ok"}
+	if targets[0].target != ts.URL+"/missing" || targets[0].status != http.StatusNotFound || !reflect.DeepEqual(targets[0].sources, sources) {
+		t.Errorf("expected /missing (404) linked from %v, got %+v", sources, targets[0])
+	}
+
+	byPage := brokenLinksByPage(broken)
+	if len(byPage) != 2 {
+		t.Fatalf("expected broken links on 2 pages, got %+v", byPage)
+	}
+	for i, p := range byPage {
+		if p.source != sources[i] || len(p.links) != 1 || p.links[0].target != ts.URL+"/missing" {
+			t.Errorf("expected %s to contain the broken link /missing, got %+v", sources[i], p)
+		}
+	}
+
+	var out bytes.Buffer
+	displayCrawl(&out, pages, 0, false)
+	expected := "Broken internal links on " + ts.URL + "/ok: 1\n  - " + ts.URL + "/missing (404), linked from 2 pages\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestPreviousCrawl(t *testing.T) {
+	before := map[string]string{
+		"/":        "testdata/changed/index_before.html",
+		"/article": "testdata/changed/article.html",
+		"/old":     "testdata/changed/old.html",
+	}
+	after := map[string]string{
+		"/":        "testdata/changed/index_after.html",
+		"/article": "testdata/changed/article.html",
+		"/new":     "testdata/changed/new.html",
+	}
+	pages := before
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, f)
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--crawl", "--format", "json", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	previous := filepath.Join(t.TempDir(), "previous.json")
+	if err := ioutil.WriteFile(previous, stdout.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pages = after
+	stdout.Reset()
+	if code := run([]string{"--crawl", "--format", "json", "--previous-crawl", previous, ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonCrawlReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rep.Added, []string{ts.URL + "/new"}) || !reflect.DeepEqual(rep.Removed, []string{ts.URL + "/old"}) {
+		t.Errorf("expected /new to be added and /old to be removed, got %v and %v", rep.Added, rep.Removed)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--crawl", "--previous-crawl", previous, ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected := "Added urls since the previous crawl: 1\n  - " + ts.URL + "/new\nRemoved urls since the previous crawl: 1\n  - " + ts.URL + "/old\n"
+	if !strings.HasSuffix(stdout.String(), expected) {
+		t.Errorf("expected output to end with\n%s\ngot\n%s", expected, stdout.String())
+	}
+}
+
+func TestEdgesCSV(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--crawl", "--format", "edges-csv", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the print version links home twice, which is one edge; fragment links to the page itself are no edges
+	want := [][]string{
+		{"source", "target"},
+		{ts.URL + "/", ts.URL + "/article"},
+		{ts.URL + "/", ts.URL + "/article/print"},
+		{ts.URL + "/", ts.URL + "/other"},
+		{ts.URL + "/article", ts.URL + "/"},
+		{ts.URL + "/article/print", ts.URL + "/"},
+		{ts.URL + "/other", ts.URL + "/"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("expected edges %v, got %v", want, rows)
+	}
+}
+
+func TestMaxTotalBytes(t *testing.T) {
+	var pageRequests int32
+	site := siteServer()
+	defer site.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			atomic.AddInt32(&pageRequests, 1)
+		}
+		site.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	//the home page of 249 bytes fits, the first page it links to exceeds the cap
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--crawl", "--format", "json", "--max-total-bytes", "300", ts.URL}, &stdout, &stderr)
+	if code != exitMaxTotalBytes {
+		t.Fatalf("expected exit code %d, got %d: %s", exitMaxTotalBytes, code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "max total bytes of 300 reached") {
+		t.Errorf("expected the cap to be reported, got %q", stderr.String())
+	}
+	if n := atomic.LoadInt32(&pageRequests); n != 2 {
+		t.Errorf("expected the crawl to stop after 2 of 4 pages, got %d requests", n)
+	}
+	var rep jsonCrawlReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Pages) != 2 || rep.Pages[0].URL != ts.URL+"/" || rep.Pages[0].Title == "" {
+		t.Errorf("expected the partial results with the analyzed home page, got %+v", rep.Pages)
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--crawl", "--format", "mermaid", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	//the same edges as TestEdgesCSV, nodes in crawl order
+	want := "graph LR\n" +
+		"  n0[\"" + ts.URL + "/\"]\n" +
+		"  n1[\"" + ts.URL + "/article\"]\n" +
+		"  n2[\"" + ts.URL + "/article/print\"]\n" +
+		"  n3[\"" + ts.URL + "/other\"]\n" +
+		"  n0 --> n1\n" +
+		"  n0 --> n2\n" +
+		"  n0 --> n3\n" +
+		"  n1 --> n0\n" +
+		"  n2 --> n0\n" +
+		"  n3 --> n0\n"
+	if out := stdout.String(); out != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+func TestMermaidEdges(t *testing.T) {
+	pages := []*crawlPage{
+		{url: "http://example.com/", links: []string{"http://example.com/a", "http://example.com/a"}},
+		{url: "http://example.com/a", links: []string{"http://example.com/\"quoted\""}},
+	}
+	var b bytes.Buffer
+	if err := writeMermaid(&b, pages); err != nil {
+		t.Fatal(err)
+	}
+	want := "graph LR\n" +
+		"  n0[\"http://example.com/\"]\n" +
+		"  n1[\"http://example.com/a\"]\n" +
+		"  n2[\"http://example.com/#quot;quoted#quot;\"]\n" +
+		"  n0 --> n1\n" +
+		"  n1 --> n2\n"
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+func TestDedupeOutput(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--crawl", "--dedupe-output", "--max-examples", "2", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if strings.Contains(out, "  Warning:") {
+		t.Errorf("expected no per page warnings, got\n%s", out)
+	}
+	want := "Warning MainLandmarkIssue on 4 pages, e.g. page has 0 main landmarks instead of one\n" +
+		"  - " + ts.URL + "/\n" +
+		"  - " + ts.URL + "/article\n" +
+		"  ... and 2 more\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output to contain\n%s\ngot\n%s", want, out)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--crawl", "--dedupe-output", "--format", "json", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonCrawlReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	for _, p := range rep.Pages {
+		if len(p.Findings) > 0 {
+			t.Errorf("expected no per page findings, %s has %v", p.URL, p.Findings)
+		}
+	}
+	for _, g := range rep.Findings {
+		if g.Kind == "MainLandmarkIssue" {
+			if g.Count != 4 || len(g.URLs) != 4 {
+				t.Errorf("expected 4 affected pages, got %d with sample %v", g.Count, g.URLs)
+			}
+			return
+		}
+	}
+	t.Errorf("expected an aggregated MainLandmarkIssue, got %+v", rep.Findings)
+}
+
+func TestFollowPagination(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/page/1": "testdata/paged/1.html",
+		"/page/2": "testdata/paged/2.html",
+		"/page/3": "testdata/paged/3.html",
+	})
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL+"/page/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("expected pagination not to be followed by default, crawled %d pages", len(pages))
+	}
+
+	pages, err = (&crawler{maxPages: 10, maxDepth: 3, followPagination: true}).crawl(context.Background(), ts.URL+"/page/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ts.URL + "/page/1", ts.URL + "/page/2", ts.URL + "/page/3"}
+	got := []string{}
+	for _, p := range pages {
+		got = append(got, p.url)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the pages in order %v, got %v", want, got)
+	}
+	if chains := paginationChains(pages); !reflect.DeepEqual(chains, [][]string{want}) {
+		t.Errorf("expected the pagination chain %v, got %v", want, chains)
+	}
+}
+
+func TestNormalizeTrailingSlash(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":          "testdata/slash/index.html",
+		"/a":         "testdata/slash/a.html",
+		"/a/":        "testdata/slash/a.html",
+		"/dir/":      "testdata/slash/dir.html",
+		"/dir/child": "testdata/slash/a.html",
+	})
+	defer ts.Close()
+	defer func() { collapseTrailingSlash = false }()
+
+	for _, collapse := range []bool{false, true} {
+		collapseTrailingSlash = collapse
+		urls := fetch(loadFixture(t, "slash/index.html")).urls
+		pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, p := range pages {
+			got = append(got, p.url)
+			if p.err != nil {
+				t.Errorf("collapse %t: expected %s to be found, got %v", collapse, p.url, p.err)
+			}
+		}
+
+		//the relative link of /dir/ resolves against /dir/, which is requested as discovered
+		wantPages := []string{ts.URL + "/", ts.URL + "/a", ts.URL + "/a/", ts.URL + "/dir/", ts.URL + "/dir/child"}
+		wantLinks := []string{ts.URL + "/a", ts.URL + "/a/", ts.URL + "/dir/"}
+		wantURLs := []string{"/a", "/a/", "/dir/"}
+		if collapse {
+			//the first href of /a and /a/ is kept as written
+			wantURLs = []string{"/a", "/dir/"}
+			wantPages = []string{ts.URL + "/", ts.URL + "/a", ts.URL + "/dir/", ts.URL + "/dir/child"}
+			wantLinks = []string{ts.URL + "/a", ts.URL + "/dir/"}
+		}
+		if !reflect.DeepEqual(urls, wantURLs) {
+			t.Errorf("collapse %t: expected urls %v, got %v", collapse, wantURLs, urls)
+		}
+		if !reflect.DeepEqual(got, wantPages) {
+			t.Errorf("collapse %t: expected crawled pages %v, got %v", collapse, wantPages, got)
+		}
+		if !reflect.DeepEqual(pages[0].links, wantLinks) {
+			t.Errorf("collapse %t: expected links %v, got %v", collapse, wantLinks, pages[0].links)
+		}
+	}
+}
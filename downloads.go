@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// downloadTypes are the file extensions of links to downloads and their type
+var downloadTypes = map[string]string{
+	".pdf":  "pdf",
+	".doc":  "document",
+	".docx": "document",
+	".odt":  "document",
+	".rtf":  "document",
+	".xls":  "spreadsheet",
+	".xlsx": "spreadsheet",
+	".ods":  "spreadsheet",
+	".csv":  "spreadsheet",
+	".ppt":  "presentation",
+	".pptx": "presentation",
+	".odp":  "presentation",
+	".zip":  "archive",
+	".rar":  "archive",
+	".7z":   "archive",
+	".tar":  "archive",
+	".gz":   "archive",
+	".tgz":  "archive",
+	".epub": "ebook",
+	".mp3":  "audio",
+	".wav":  "audio",
+	".mp4":  "video",
+	".mov":  "video",
+	".exe":  "installer",
+	".msi":  "installer",
+	".dmg":  "installer",
+	".apk":  "installer",
+}
+
+// downloadType returns the type of a link by the extension of its path, "" if it is no download
+func downloadType(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+	return downloadTypes[strings.ToLower(path.Ext(u.Path))]
+}
+
+// getDownloads groups the links to downloads by type
+func getDownloads(links []string) map[string][]string {
+	downloads := map[string][]string{}
+	for _, l := range links {
+		if t := downloadType(l); t != "" {
+			downloads[t] = append(downloads[t], l)
+		}
+	}
+	return downloads
+}
+
+// writeDownloads prints the number of downloads per type sorted by type, followed by up to maxExamples links
+func writeDownloads(w io.Writer, downloads map[string][]string, maxExamples int) {
+	types := []string{}
+	for t := range downloads {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	fmt.Fprintln(w, "Downloads by type:")
+	for _, t := range types {
+		fmt.Fprintf(w, "%d - %s\n", len(downloads[t]), t)
+		writeExamples(w, "  ", downloads[t], maxExamples)
+	}
+}
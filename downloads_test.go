@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDownloads(t *testing.T) {
+	fr := fetch(loadFixture(t, "downloads.html"))
+	want := map[string][]string{
+		"pdf":     {"/files/annual-report.PDF"},
+		"archive": {"https://cdn.example.com/release/app-1.2.zip?sig=abc"},
+	}
+	if !reflect.DeepEqual(fr.downloads, want) {
+		t.Fatalf("expected %v, got %v", want, fr.downloads)
+	}
+
+	var out bytes.Buffer
+	writeDownloads(&out, fr.downloads, 0)
+	expected := "Downloads by type:\n1 - archive\n  - https://cdn.example.com/release/app-1.2.zip?sig=abc\n1 - pdf\n  - /files/annual-report.PDF\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// nearDuplicateDistance is the maximum number of differing simhash bits of near-duplicate pages
+const nearDuplicateDistance = 3
+
+// visibleText returns the normalized text of body: without scripts and styles, lower case, single spaced
+func visibleText(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	return strings.ToLower(strings.Join(strings.Fields(body.Text()), " "))
+}
+
+// hashText returns a sha256 of text for exact duplicates and a simhash of its words for near duplicates
+func hashText(text string) (string, uint64) {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:]), simhash(strings.Fields(text))
+}
+
+// simhash computes a 64 bit simhash of the word shingles (3 words) of words
+// similar texts have hashes differing in few bits
+func simhash(words []string) uint64 {
+	var weights [64]int
+	add := func(s string) {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		v := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if v&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(words) < 3 {
+		add(strings.Join(words, " "))
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		add(strings.Join(words[i:i+3], " "))
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// duplicateGroups groups the urls of successfully fetched pages with identical text,
+// and of pages which are not identical but within nearDuplicateDistance of each other
+// groups with a single page are omitted
+func duplicateGroups(pages []*crawlPage) (duplicates [][]string, nearDuplicates [][]string) {
+	byHash := map[string][]string{}
+	hashes := []string{}
+	var firsts []*crawlPage
+	for _, p := range pages {
+		if p.result == nil {
+			continue
+		}
+		if _, ok := byHash[p.textHash]; !ok {
+			hashes = append(hashes, p.textHash)
+			firsts = append(firsts, p)
+		}
+		byHash[p.textHash] = append(byHash[p.textHash], p.url)
+	}
+	for _, h := range hashes {
+		if len(byHash[h]) > 1 {
+			duplicates = append(duplicates, byHash[h])
+		}
+	}
+
+	//near duplicates are compared between distinct texts, each joins the group of the first close page
+	grouped := make([]bool, len(firsts))
+	for i, a := range firsts {
+		if grouped[i] {
+			continue
+		}
+		group := append([]string{}, byHash[a.textHash]...)
+		for j := i + 1; j < len(firsts); j++ {
+			b := firsts[j]
+			if !grouped[j] && bits.OnesCount64(a.simhash^b.simhash) <= nearDuplicateDistance {
+				grouped[j] = true
+				group = append(group, byHash[b.textHash]...)
+			}
+		}
+		if len(group) > len(byHash[a.textHash]) {
+			nearDuplicates = append(nearDuplicates, group)
+		}
+	}
+	return duplicates, nearDuplicates
+}
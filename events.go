@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// event types sent while crawling and checking links
+const (
+	eventPageStarted  = "PageStarted"
+	eventPageFinished = "PageFinished"
+	eventLinkChecked  = "LinkChecked"
+)
+
+// event reports progress, it is serialized as one NDJSON line
+type event struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"timestamp"`
+	URL    string    `json:"url"`
+	Status int       `json:"status,omitempty"`
+	Result string    `json:"result,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// emit timestamps e and sends it to events, a nil channel discards it
+func emit(events chan<- event, e event) {
+	if events == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	events <- e
+}
+
+// writeNDJSON writes every event as a JSON line until events is closed
+// after a write error the remaining events are drained so senders never block
+func writeNDJSON(w io.Writer, events <-chan event) error {
+	enc := json.NewEncoder(w)
+	var err error
+	for e := range events {
+		if err == nil {
+			err = enc.Encode(e)
+		}
+	}
+	return err
+}
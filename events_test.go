@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNDJSONEvents(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+
+	events := make(chan event)
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() { done <- writeNDJSON(&buf, events) }()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3, events: events}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(&linkChecker{workers: 2, events: events}).check(context.Background(), []string{ts.URL + "/article", ts.URL + "/missing"})
+	close(events)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		typ, _ := line["type"].(string)
+		if stamp, _ := line["timestamp"].(string); stamp == "" {
+			t.Errorf("expected a timestamp in %q", scanner.Text())
+		}
+		counts[typ]++
+	}
+	want := map[string]int{eventPageStarted: len(pages), eventPageFinished: len(pages), eventLinkChecked: 2}
+	for typ, n := range want {
+		if counts[typ] != n {
+			t.Errorf("expected %d %s events, got %d", n, typ, counts[typ])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// patternList is a repeatable flag of regular expressions
+type patternList []*regexp.Regexp
+
+func (p *patternList) String() string {
+	s := []string{}
+	for _, r := range *p {
+		s = append(s, r.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// Set compiles and adds a pattern
+func (p *patternList) Set(v string) error {
+	r, err := regexp.Compile(v)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, r)
+	return nil
+}
+
+// matches returns true if any pattern matches s
+func (p patternList) matches(s string) bool {
+	for _, r := range p {
+		if r.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlFilter decides which discovered urls are crawled or pinged
+type urlFilter struct {
+	include patternList
+	ignore  patternList
+}
+
+// allowed returns false for urls matching an ignore pattern
+// if include patterns are set, urls must match one of them; a nil filter allows everything
+func (f *urlFilter) allowed(u string) bool {
+	if f == nil {
+		return true
+	}
+	if f.ignore.matches(u) {
+		return false
+	}
+	return len(f.include) == 0 || f.include.matches(u)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// finding is a problem found on a page, kind identifies the check which found it
+// examples lists the affected elements or urls of list-type findings
+type finding struct {
+	kind     string
+	message  string
+	examples []string
+}
+
+// findings derives the problems found on the page from the fetchResult
+func (fr *fetchResult) findings() []finding {
+	f := []finding{}
+	if fr.ran("robots") && !fr.crawlable {
+		f = append(f, finding{kind: "NotCrawlable", message: "page is not crawlable: " + fr.crawlReason})
+	}
+	if fr.missingDoctype {
+		f = append(f, finding{kind: "MissingDoctype", message: "page has no doctype and is rendered in quirks mode"})
+	}
+	if fr.thinContent {
+		f = append(f, finding{kind: "ThinContent", message: fmt.Sprintf("page has only %d visible words, less than %d", fr.wordCount, limits.minWords)})
+	}
+	if fr.missingH1 {
+		f = append(f, finding{kind: "MissingH1", message: "page has no h1"})
+	}
+	if fr.multipleH1 {
+		f = append(f, finding{kind: "MultipleH1", message: fmt.Sprintf("page has %d h1", len(fr.h1Texts)), examples: fr.h1Texts})
+	}
+	if fr.ran("a11y") && fr.mainLandmarks != 1 {
+		f = append(f, finding{kind: "MainLandmarkIssue", message: fmt.Sprintf("page has %d main landmarks instead of one", fr.mainLandmarks)})
+	}
+	if len(fr.danglingLabels) > 0 {
+		f = append(f, finding{kind: "DanglingLabel", message: fmt.Sprintf("%d labels refer to missing ids", len(fr.danglingLabels)), examples: fr.danglingLabels})
+	}
+	if len(fr.sharedLabelTargets) > 0 {
+		f = append(f, finding{kind: "SharedLabelTarget", message: fmt.Sprintf("%d ids are referenced by more than one label", len(fr.sharedLabelTargets)), examples: fr.sharedLabelTargets})
+	}
+	if len(fr.invalidAriaRoles) > 0 {
+		f = append(f, finding{kind: "InvalidAriaRoles", message: fmt.Sprintf("%d role values are not ARIA roles", len(fr.invalidAriaRoles)), examples: fr.invalidAriaRoles})
+	}
+	if len(fr.duplicateAccesskeys) > 0 {
+		f = append(f, finding{kind: "DuplicateAccesskeys", message: fmt.Sprintf("%d accesskeys are assigned to more than one element", len(fr.duplicateAccesskeys)), examples: fr.duplicateAccesskeys})
+	}
+	if len(fr.svgIssues) > 0 {
+		f = append(f, finding{kind: "SvgAccessibilityIssues", message: fmt.Sprintf("%d inline svgs are not accessible images", len(fr.svgIssues)), examples: fr.svgIssues})
+	}
+	if len(fr.nestedInteractive) > 0 {
+		f = append(f, finding{kind: "NestedInteractive", message: fmt.Sprintf("%d interactive elements are nested in links or buttons, which breaks keyboard and screen reader use", len(fr.nestedInteractive)), examples: fr.nestedInteractive})
+	}
+	if len(fr.skippedHeadings) > 0 {
+		f = append(f, finding{kind: "SkippedHeadingLevels", message: fmt.Sprintf("%d headings skip a heading level", len(fr.skippedHeadings)), examples: fr.skippedHeadings})
+	}
+	if len(fr.unlabeledFields) > 0 {
+		f = append(f, finding{kind: "UnlabeledFields", message: fmt.Sprintf("%d form fields have no label", len(fr.unlabeledFields)), examples: fr.unlabeledFields})
+	}
+	if len(fr.missingAlt) > 0 {
+		f = append(f, finding{kind: "MissingAlt", message: fmt.Sprintf("%d images have no alt attribute", len(fr.missingAlt)), examples: fr.missingAlt})
+	}
+	if fr.missingSkipLink {
+		f = append(f, finding{kind: "MissingSkipLink", message: "page has a navigation but its first link does not skip to the content"})
+	}
+	if len(fr.autofocus) > 0 {
+		f = append(f, finding{kind: "Autofocus", message: fmt.Sprintf("%d elements take the focus on load with autofocus", len(fr.autofocus)), examples: fr.autofocus})
+	}
+	if len(fr.lowContrast) > 0 {
+		f = append(f, finding{kind: "ContrastWarnings", message: fmt.Sprintf("%d elements have inline colors with low contrast", len(fr.lowContrast)), examples: fr.lowContrast})
+	}
+	if len(fr.autoplayMedia) > 0 {
+		f = append(f, finding{kind: "AutoplayMedia", message: fmt.Sprintf("%d media elements play automatically", len(fr.autoplayMedia)), examples: fr.autoplayMedia})
+	}
+	if len(fr.zoomDisabled) > 0 {
+		f = append(f, finding{kind: "MobileUsability", message: "viewport disables zooming: " + strings.Join(fr.zoomDisabled, ", ")})
+	}
+	if len(fr.viewportIssues) > 0 {
+		f = append(f, finding{kind: "ViewportIssues", message: fmt.Sprintf("viewport meta has %d issues for responsive design", len(fr.viewportIssues)), examples: fr.viewportIssues})
+	}
+	if len(fr.wideElements) > 0 {
+		f = append(f, finding{kind: "MobileUsability", message: fmt.Sprintf("%d elements have an inline width over %dpx", len(fr.wideElements), maxMobileWidth), examples: fr.wideElements})
+	}
+	if fr.metaDescriptionIssue != "" {
+		kind := "MetaDescriptionLength"
+		if !fr.hasMetaDescription {
+			kind = "MissingMetaDescription"
+		}
+		f = append(f, finding{kind: kind, message: fr.metaDescriptionIssue})
+	}
+	if len(fr.canonicalTargets) > 0 {
+		urls := []string{}
+		for _, t := range fr.canonicalTargets {
+			urls = append(urls, t.url)
+		}
+		f = append(f, finding{kind: "CanonicalTargets", message: fmt.Sprintf("canonical points to %d other pages", len(urls)), examples: urls})
+	}
+	if fr.canonicalUnreachable != "" {
+		f = append(f, finding{kind: "CanonicalUnreachable", message: "canonical target can not be analyzed: " + fr.canonicalUnreachable})
+	}
+	if fr.canonicalLoop != "" {
+		f = append(f, finding{kind: "CanonicalLoop", message: "canonical loop " + fr.canonicalLoop})
+	}
+	if fr.canonicalOgURLMismatch {
+		f = append(f, finding{kind: "CanonicalOgUrlMismatch", message: fmt.Sprintf("canonical %s and og:url %s differ", fr.canonical, fr.ogURL)})
+	}
+	if len(fr.rawURLAnchors) > 0 {
+		f = append(f, finding{kind: "RawURLAnchors", message: fmt.Sprintf("%d links use the bare url as text", len(fr.rawURLAnchors)), examples: fr.rawURLAnchors})
+	}
+	if fr.deepURL {
+		f = append(f, finding{kind: "DeepURLs", message: fmt.Sprintf("url has %d path segments, more than %d", fr.pathDepth, limits.maxPathDepth), examples: []string{fr.url}})
+	}
+	if len(fr.malformedLinks) > 0 {
+		f = append(f, finding{kind: "MalformedLinks", message: fmt.Sprintf("%d links have a malformed path, which often is a templating bug", len(fr.malformedLinks)), examples: fr.malformedLinks})
+	}
+	if len(fr.deprecatedProtocolLinks) > 0 {
+		f = append(f, finding{kind: "DeprecatedProtocolLinks", message: fmt.Sprintf("%d links use a deprecated or risky protocol like ftp: or file:", len(fr.deprecatedProtocolLinks)), examples: fr.deprecatedProtocolLinks})
+	}
+	if fr.placeholderLinks > 0 {
+		f = append(f, finding{kind: "PlaceholderLinks", message: fmt.Sprintf("%d links have a placeholder href like # or javascript:void(0), they may need to be buttons", fr.placeholderLinks)})
+	}
+	if len(fr.insecureHops) > 0 {
+		f = append(f, finding{kind: "InsecureRedirectHop", message: fmt.Sprintf("redirect chain from https goes through %d http urls", len(fr.insecureHops)), examples: fr.insecureHops})
+	}
+	if len(fr.missingSRI) > 0 {
+		f = append(f, finding{kind: "MissingSRI", message: fmt.Sprintf("%d cross-origin scripts and stylesheets are not protected by Subresource Integrity", len(fr.missingSRI)), examples: fr.missingSRI})
+	}
+	if len(fr.formsWithoutCSRFToken) > 0 {
+		f = append(f, finding{kind: "PossibleCSRFMissing", message: fmt.Sprintf("heuristic: %d POST forms have no hidden csrf token field, check they are protected otherwise", len(fr.formsWithoutCSRFToken)), examples: fr.formsWithoutCSRFToken})
+	}
+	for _, w := range fr.cache.warnings {
+		f = append(f, finding{kind: "CacheHeaders", message: w})
+	}
+	if fr.conditionalGet.ignored() {
+		f = append(f, finding{kind: "ConditionalGetIgnored", message: fmt.Sprintf("server ignores %s and sends the full page again", strings.Join(fr.conditionalGet.validators, " and "))})
+	}
+	if fr.settle.changed() {
+		f = append(f, finding{kind: "DynamicContentDetected", message: fr.settle.String()})
+	}
+	if fr.renderBlockingCSS > 0 {
+		f = append(f, finding{kind: "RenderBlockingCSS", message: fmt.Sprintf("%d stylesheets in head block rendering", fr.renderBlockingCSS)})
+	}
+	if len(fr.preloadsMissingAs) > 0 {
+		f = append(f, finding{kind: "PreloadMissingAs", message: fmt.Sprintf("%d preloads without as attribute", len(fr.preloadsMissingAs)), examples: fr.preloadsMissingAs})
+	}
+	if fr.excessiveFonts {
+		f = append(f, finding{kind: "ExcessiveFonts", message: fmt.Sprintf("page loads %d font resources, more than %d", len(fr.fonts), limits.maxFonts), examples: fr.fonts})
+	}
+	if len(fr.trackingPixels) > 0 {
+		f = append(f, finding{kind: "TrackingPixels", message: fmt.Sprintf("%d 1x1 images look like tracking pixels", len(fr.trackingPixels)), examples: fr.trackingPixels})
+	}
+	if fr.structuredData.withoutJSONLD() {
+		f = append(f, finding{kind: "StructuredDataWithoutJSONLD", message: "structured data uses microdata or RDFa without JSON-LD"})
+	}
+	if fr.xhtmlNamespace {
+		msg := "html element declares the XHTML namespace"
+		if fr.version == "HTML 5" {
+			msg += " with an HTML5 doctype, which is usually unintended"
+		}
+		f = append(f, finding{kind: "NamespaceWarning", message: msg})
+	}
+	if len(fr.presentationalAttributes) > 0 {
+		n, attrs := 0, []string{}
+		for attr, count := range fr.presentationalAttributes {
+			n += count
+			attrs = append(attrs, fmt.Sprintf("%s: %d", attr, count))
+		}
+		sort.Strings(attrs)
+		f = append(f, finding{kind: "PresentationalAttributes", message: fmt.Sprintf("%d elements use obsolete presentational attributes, use CSS instead", n), examples: attrs})
+	}
+	if fr.conditionalComments > 0 {
+		f = append(f, finding{kind: "ConditionalComments", message: fmt.Sprintf("%d IE conditional comments", fr.conditionalComments)})
+	}
+	return f
+}
+
+// writeExamples prints up to max examples (all if max is 0) and how many more were left out
+func writeExamples(w io.Writer, indent string, examples []string, max int) {
+	for i, e := range examples {
+		if max > 0 && i == max {
+			fmt.Fprintf(w, "%s... and %d more\n", indent, len(examples)-max)
+			return
+		}
+		fmt.Fprintf(w, "%s- %s\n", indent, e)
+	}
+}
@@ -2,4 +2,10 @@ module main.go
 
 go 1.14
 
-require github.com/PuerkitoBio/goquery v1.5.1
+require (
+	github.com/PuerkitoBio/goquery v1.5.1
+	github.com/golang/protobuf v1.4.2
+	golang.org/x/net v0.0.0-20200202094626-16171245cfb2
+	google.golang.org/grpc v1.36.1
+	google.golang.org/protobuf v1.25.0
+)
@@ -0,0 +1,87 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative analyze.proto
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// analyzerServer implements the Analyzer service of analyze.proto for --grpc-listen
+type analyzerServer struct {
+	UnimplementedAnalyzerServer
+	//newChecker returns the link checker of a request, each request has its own retry budget
+	newChecker func() *linkChecker
+}
+
+// Analyze returns the report of --format json for the requested url
+func (s *analyzerServer) Analyze(ctx context.Context, req *AnalyzeRequest) (*Report, error) {
+	if req.Url == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing url")
+	}
+	rep, err := analyzeReport(ctx, req.Url, s.newChecker())
+	if err != nil {
+		//the page could not be fetched or parsed
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return newProtoReport(rep), nil
+}
+
+// analyzeReport analyzes url and checks its internal links like a single page run and returns its jsonReport
+func analyzeReport(ctx context.Context, url string, checker *linkChecker) (jsonReport, error) {
+	fr, err := analyzePage(ctx, url)
+	if err != nil {
+		return jsonReport{}, err
+	}
+	return newJSONReport(fr, sortLinks(ctx, fr.urls, fr.linkBase(), checker)), nil
+}
+
+// newProtoReport converts a jsonReport to the Report message, which contains a subset of its fields
+func newProtoReport(rep jsonReport) *Report {
+	pr := &Report{
+		Url:      rep.URL,
+		Title:    rep.Title,
+		Version:  rep.Version,
+		Headings: map[string]int32{},
+		Links: &Links{
+			Internal:     int32(rep.Links.Internal),
+			External:     int32(rep.Links.External),
+			Inaccessible: int32(rep.Links.Inaccessible),
+			Timeouts:     int32(rep.Links.Timeouts),
+			NotChecked:   int32(rep.Links.NotChecked),
+		},
+	}
+	for h, n := range rep.Headings {
+		pr.Headings[h] = int32(n)
+	}
+	for _, r := range rep.Redirects {
+		pr.Redirects = append(pr.Redirects, &Redirect{Url: r.URL, Status: int32(r.Status)})
+	}
+	for _, l := range rep.Links.Checked {
+		pr.Links.Checked = append(pr.Links.Checked, &Link{Url: l.URL, Status: l.Status, Code: int32(l.Code), Error: l.Error})
+	}
+	for _, f := range rep.Findings {
+		pr.Findings = append(pr.Findings, &Finding{Kind: f.Kind, Message: f.Message, Examples: f.Examples})
+	}
+	return pr
+}
+
+// serveGRPC serves the Analyzer service on l until ctx is done, in-flight requests are finished before it returns
+func serveGRPC(ctx context.Context, l net.Listener, newChecker func() *linkChecker) error {
+	srv := grpc.NewServer()
+	RegisterAnalyzerServer(srv, &analyzerServer{newChecker: newChecker})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			srv.GracefulStop()
+		case <-done:
+		}
+	}()
+	return srv.Serve(l)
+}
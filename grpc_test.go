@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCAnalyze(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":      "testdata/deprecated_protocols.html",
+		"/about": "testdata/plain.html",
+	})
+	defer ts.Close()
+
+	l := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- serveGRPC(ctx, l, func() *linkChecker { return &linkChecker{workers: 2} })
+	}()
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return l.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewAnalyzerClient(conn)
+
+	got, err := client.Analyze(context.Background(), &AnalyzeRequest{Url: ts.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the service returns the report of --format json
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "json", ts.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != rep.Title || got.Url != rep.URL || int(got.Links.Internal) != rep.Links.Internal || int(got.Links.External) != rep.Links.External {
+		t.Errorf("expected the report %+v, got %v", rep, got)
+	}
+	if len(got.Links.Checked) != 1 || got.Links.Checked[0].Url != ts.URL+"/about" || got.Links.Checked[0].Status != rep.Links.Checked[0].Status {
+		t.Errorf("expected the checked link of the json report, got %v", got.Links.Checked)
+	}
+	if len(got.Findings) != len(rep.Findings) {
+		t.Fatalf("expected %d findings, got %v", len(rep.Findings), got.Findings)
+	}
+	for i, f := range rep.Findings {
+		if got.Findings[i].Kind != f.Kind || got.Findings[i].Message != f.Message {
+			t.Errorf("expected finding %+v, got %v", f, got.Findings[i])
+		}
+	}
+
+	if _, err := client.Analyze(context.Background(), &AnalyzeRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without url, got %v", err)
+	}
+	if _, err := client.Analyze(context.Background(), &AnalyzeRequest{Url: ts.URL + "/missing"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable for a missing page, got %v", err)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("expected the server to stop with the context, got %v", err)
+	}
+}
+
+func TestGRPCListenOptions(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--grpc-listen", "127.0.0.1:0", "http://example.com/"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d for --grpc-listen with a url, got %d", exitUsage, code)
+	}
+	//the server runs until the max runtime
+	if code := run([]string{"--grpc-listen", "127.0.0.1:0", "--max-runtime", "100ms"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("expected exit code 0 after the max runtime, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "grpc server listening on 127.0.0.1:") {
+		t.Errorf("expected the listen address on stderr, got %q", stderr.String())
+	}
+}
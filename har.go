@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// harFile is the subset of the HAR 1.2 format needed to replay responses
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status     int    `json:"status"`
+		StatusText string `json:"statusText"`
+		Headers    []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// harTransport is a http.RoundTripper which answers requests from the entries of a HAR file
+// requests without a matching entry fail, so nothing goes over the network
+type harTransport struct {
+	entries map[string]harEntry
+}
+
+// loadHAR reads a HAR file and returns a transport replaying its entries
+func loadHAR(path string) (*harTransport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var h harFile
+	if err := json.NewDecoder(f).Decode(&h); err != nil {
+		return nil, fmt.Errorf("Error parsing HAR file %s: %v", path, err)
+	}
+
+	t := &harTransport{entries: map[string]harEntry{}}
+	for _, e := range h.Log.Entries {
+		key, err := harKey(e.Request.Method, e.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		//keep the first response if an url was requested more than once
+		if _, ok := t.entries[key]; !ok {
+			t.entries[key] = e
+		}
+	}
+	return t, nil
+}
+
+// harKey normalizes method and url so that equivalent requests match the same entry
+func harKey(method, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	return strings.ToUpper(method) + " " + u.String(), nil
+}
+
+// RoundTrip returns the stored response for the request
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := harKey(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	e, ok := t.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("no HAR entry for %s", key)
+	}
+
+	body := e.Response.Content.Text
+	if e.Response.Content.Encoding == "base64" {
+		b, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding HAR content for %s: %v", key, err)
+		}
+		body = string(b)
+	}
+
+	header := http.Header{}
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	if header.Get("Content-Type") == "" && e.Response.Content.MimeType != "" {
+		header.Set("Content-Type", e.Response.Content.MimeType)
+	}
+	header.Del("Content-Encoding") //the stored text is already decoded
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText),
+		StatusCode:    e.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestHARReplay(t *testing.T) {
+	tr, err := loadHAR("testdata/sample.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(orig http.RoundTripper) { client.Transport = orig }(client.Transport)
+	client.Transport = tr
+
+	doc, _, err := parsePage(context.Background(), "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := fetch(doc)
+	if fr.title != "Replayed page" {
+		t.Fatalf("expected title 'Replayed page', got '%s'", fr.title)
+	}
+	if fr.version != "HTML 5" {
+		t.Fatalf("expected version 'HTML 5', got '%s'", fr.version)
+	}
+	if len(fr.urls) != 3 {
+		t.Fatalf("expected 3 urls, got %v", fr.urls)
+	}
+
+	//"/about" is stored in the HAR, "/missing" is not
+	sr := sortLinks(context.Background(), fr.urls, "http://example.com/", &linkChecker{workers: 2})
+	if sr.internals != 2 {
+		t.Fatalf("expected 2 internal links, got %d", sr.internals)
+	}
+	if sr.inaccessible != 1 {
+		t.Fatalf("expected 1 inaccessible link, got %d", sr.inaccessible)
+	}
+}
+
+func TestHARMissingEntry(t *testing.T) {
+	tr, err := loadHAR("testdata/sample.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(orig http.RoundTripper) { client.Transport = orig }(client.Transport)
+	client.Transport = tr
+
+	if _, _, err := parsePage(context.Background(), "http://example.com/missing"); err == nil {
+		t.Fatal("expected an error for an url which is not in the HAR file")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// requestJitter delays every request by a random duration if not nil, see getWithContext
+var requestJitter *jitter
+
+// jitter spreads requests of parallel workers over a window so they don't hit a server in waves
+// it is safe for concurrent use
+type jitter struct {
+	mu  sync.Mutex
+	max time.Duration
+	rng *rand.Rand
+}
+
+// newJitter returns a jitter of up to max, the same seed gives the same sequence of delays
+func newJitter(max time.Duration, seed int64) *jitter {
+	return &jitter{max: max, rng: rand.New(rand.NewSource(seed))}
+}
+
+// delay returns the next random duration in [0, max)
+func (j *jitter) delay() time.Duration {
+	if j.max <= 0 {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return time.Duration(j.rng.Int63n(int64(j.max)))
+}
+
+// wait sleeps for the next delay or until ctx is done
+func (j *jitter) wait(ctx context.Context) error {
+	t := time.NewTimer(j.delay())
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJitterSeed(t *testing.T) {
+	a, b := newJitter(time.Second, 42), newJitter(time.Second, 42)
+	var da, db []time.Duration
+	for i := 0; i < 10; i++ {
+		da = append(da, a.delay())
+		db = append(db, b.delay())
+	}
+	if !reflect.DeepEqual(da, db) {
+		t.Fatalf("expected the same seed to give the same delays, got %v and %v", da, db)
+	}
+	for _, d := range da {
+		if d < 0 || d >= time.Second {
+			t.Fatalf("expected delays within the jitter window, got %s", d)
+		}
+	}
+}
+
+func TestJitterSpreadsRequests(t *testing.T) {
+	const window = 300 * time.Millisecond
+	var mu sync.Mutex
+	arrivals := []time.Time{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	defer func(orig *jitter) { requestJitter = orig }(requestJitter)
+	requestJitter = newJitter(window, 7)
+
+	//all requests start at once, the jitter spreads them over the window
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := get(ts.URL); err == nil {
+				res.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(arrivals) != 8 {
+		t.Fatalf("expected 8 requests, got %d", len(arrivals))
+	}
+	first, last := arrivals[0], arrivals[0]
+	for _, a := range arrivals {
+		if a.Sub(start) > window+200*time.Millisecond {
+			t.Errorf("expected requests within the jitter window, got one after %s", a.Sub(start))
+		}
+		if a.Before(first) {
+			first = a
+		}
+		if a.After(last) {
+			last = a
+		}
+	}
+	if last.Sub(first) < 20*time.Millisecond {
+		t.Errorf("expected requests to be spread, all arrived within %s", last.Sub(first))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// junitSuites is the root of a JUnit XML report as read by Jenkins and GitLab
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes a suite with a test case per checked link, failing for inaccessible and timed out links,
+// and a suite with a failing test case per finding
+func writeJUnit(w io.Writer, pageURL string, fr *fetchResult, r *sortResult) error {
+	links := junitSuite{Name: "links"}
+	for _, l := range r.links {
+		c := junitCase{ClassName: pageURL, Name: l.url}
+		switch l.status {
+		case linkDown, linkTimeout:
+			msg := fmt.Sprintf("status %d", l.code)
+			if l.err != nil {
+				msg = l.err.Error()
+			}
+			c.Failure = &junitFailure{Message: msg, Type: l.status.String()}
+			links.Failures++
+		case linkNotChecked:
+			c.Skipped = &junitSkipped{Message: "not checked before the deadline"}
+			links.Skipped++
+		}
+		links.Cases = append(links.Cases, c)
+	}
+	links.Tests = len(links.Cases)
+
+	findings := junitSuite{Name: "findings"}
+	for _, f := range fr.findings() {
+		findings.Cases = append(findings.Cases, junitCase{
+			ClassName: pageURL,
+			Name:      f.kind,
+			Failure:   &junitFailure{Message: f.message, Type: f.kind, Text: strings.Join(f.examples, "\n")},
+		})
+	}
+	findings.Tests = len(findings.Cases)
+	findings.Failures = len(findings.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{links, findings}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
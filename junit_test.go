@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	fr := &fetchResult{crawlable: true, mainLandmarks: 1, renderBlockingCSS: 2}
+	r := &sortResult{links: []linkResult{
+		{url: "http://example.com/ok?a=1&b=2", status: linkOK, code: 200},
+		{url: "http://example.com/<broken>", status: linkDown, code: 404},
+		{url: "http://example.com/slow", status: linkTimeout, err: errors.New(`deadline "exceeded"`)},
+		{url: "http://example.com/later", status: linkNotChecked},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeJUnit(&buf, "http://example.com/", fr, r); err != nil {
+		t.Fatal(err)
+	}
+	var got junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(got.Suites) != 2 {
+		t.Fatalf("expected 2 test suites, got %d", len(got.Suites))
+	}
+
+	links := got.Suites[0]
+	if links.Tests != 4 || len(links.Cases) != 4 || links.Failures != 2 || links.Skipped != 1 {
+		t.Errorf("expected 4 link test cases with 2 failures and 1 skipped, got %+v", links)
+	}
+	if links.Cases[1].Name != "http://example.com/<broken>" || links.Cases[1].Failure == nil {
+		t.Errorf("expected the broken link to fail, got %+v", links.Cases[1])
+	}
+	if links.Cases[2].Failure == nil || links.Cases[2].Failure.Message != `deadline "exceeded"` {
+		t.Errorf("expected the timeout message to be kept, got %+v", links.Cases[2].Failure)
+	}
+
+	findings := got.Suites[1]
+	if findings.Tests != 1 || findings.Failures != 1 || findings.Cases[0].Name != "RenderBlockingCSS" {
+		t.Errorf("expected 1 failing RenderBlockingCSS finding, got %+v", findings)
+	}
+}
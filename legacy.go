@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// countComments walks the node tree and returns the number of comments
+// and how many of them are IE conditional comments (<!--[if IE]> or <![if !IE]>)
+func countComments(doc *goquery.Document) (comments int, conditional int) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.CommentNode {
+			comments++
+			if strings.HasPrefix(strings.TrimSpace(n.Data), "[if ") {
+				conditional++
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range doc.Nodes {
+		walk(n)
+	}
+	return comments, conditional
+}
+
+// hasDoctype returns true if the document declares any doctype, known to versionReader or not
+// pages without one are rendered in quirks mode
+func hasDoctype(doc *goquery.Document) bool {
+	for _, n := range doc.Nodes {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.DoctypeNode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// xhtmlNamespace is the namespace of XHTML documents, HTML parsers ignore it
+const xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+
+// declaresXHTMLNamespace returns true if the html element has the XHTML xmlns attribute
+func declaresXHTMLNamespace(doc *goquery.Document) bool {
+	return strings.TrimSpace(doc.Find("html").First().AttrOr("xmlns", "")) == xhtmlNamespace
+}
+
+// presentationalAttributes are obsolete presentational attributes and the elements on which they are obsolete
+// attributes of other elements, e.g. in svg, are not counted
+var presentationalAttributes = map[string][]string{
+	"align":   {"caption", "col", "colgroup", "div", "embed", "h1", "h2", "h3", "h4", "h5", "h6", "hr", "iframe", "img", "input", "legend", "object", "p", "table", "tbody", "td", "tfoot", "th", "thead", "tr"},
+	"bgcolor": {"body", "table", "td", "th", "tr"},
+	"valign":  {"col", "colgroup", "tbody", "td", "tfoot", "th", "thead", "tr"},
+	"border":  {"img", "object", "table"},
+}
+
+// countPresentationalAttributes counts the elements using each obsolete presentational attribute
+func countPresentationalAttributes(doc *goquery.Document) map[string]int {
+	counts := map[string]int{}
+	for attr, elements := range presentationalAttributes {
+		selectors := []string{}
+		for _, e := range elements {
+			selectors = append(selectors, e+"["+attr+"]")
+		}
+		if n := doc.Find(strings.Join(selectors, ", ")).Length(); n > 0 {
+			counts[attr] = n
+		}
+	}
+	return counts
+}
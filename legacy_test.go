@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountComments(t *testing.T) {
+	comments, conditional := countComments(loadFixture(t, "comments.html"))
+	//2 regular comments, 2 conditional comments and the downlevel-revealed <![if !IE]> with its <![endif]>
+	if comments != 6 {
+		t.Errorf("expected 6 comments, got %d", comments)
+	}
+	if conditional != 3 {
+		t.Errorf("expected 3 conditional comments, got %d", conditional)
+	}
+}
+
+func TestDoctype(t *testing.T) {
+	tests := []struct {
+		fixture string
+		version string
+		missing bool
+	}{
+		{"doctype_none.html", "", true},
+		{"plain.html", "HTML 5", false},
+		{"doctype_unknown.html", "", false},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if fr.version != tt.version {
+			t.Errorf("%s: expected version %q, got %q", tt.fixture, tt.version, fr.version)
+		}
+		if fr.missingDoctype != tt.missing || hasFinding(fr, "MissingDoctype") != tt.missing {
+			t.Errorf("%s: expected MissingDoctype %t", tt.fixture, tt.missing)
+		}
+	}
+}
+
+func TestNamespaceWarning(t *testing.T) {
+	if fr := fetch(loadFixture(t, "plain.html")); fr.xhtmlNamespace || hasFinding(fr, "NamespaceWarning") {
+		t.Errorf("expected no NamespaceWarning for a plain HTML5 page")
+	}
+	fr := fetch(loadFixture(t, "xhtml_namespace.html"))
+	if !fr.xhtmlNamespace {
+		t.Errorf("expected the XHTML namespace to be detected")
+	}
+	for _, f := range fr.findings() {
+		if f.kind == "NamespaceWarning" {
+			if want := "html element declares the XHTML namespace with an HTML5 doctype, which is usually unintended"; f.message != want {
+				t.Errorf("expected %q, got %q", want, f.message)
+			}
+			return
+		}
+	}
+	t.Errorf("expected a NamespaceWarning finding, got %v", fr.findings())
+}
+
+func TestPresentationalAttributes(t *testing.T) {
+	fr := fetch(loadFixture(t, "presentational.html"))
+	want := map[string]int{"align": 4, "bgcolor": 3, "valign": 1, "border": 1}
+	if !reflect.DeepEqual(fr.presentationalAttributes, want) {
+		t.Errorf("expected %v, got %v", want, fr.presentationalAttributes)
+	}
+	if !hasFinding(fr, "PresentationalAttributes") {
+		t.Errorf("expected a PresentationalAttributes finding, got %v", fr.findings())
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "PresentationalAttributes") {
+		t.Error("expected no PresentationalAttributes finding for a plain page")
+	}
+}
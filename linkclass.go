@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// link classes of --classify-links
+const (
+	//linkSamePage links point to a fragment of the page itself
+	linkSamePage = "same-page"
+	//linkSameSite links point to another page of the same registered domain, e.g. www.example.com and blog.example.com
+	linkSameSite  = "same-site"
+	linkCrossSite = "cross-site"
+)
+
+// linkClasses are the link classes in the order they are printed
+var linkClasses = []string{linkSamePage, linkSameSite, linkCrossSite}
+
+// classifiedLink is a discovered link with its link class
+type classifiedLink struct {
+	url   string
+	class string
+}
+
+// classifyLinks classifies the links found on pageURL
+// links are resolved against baseURL, the <base href> or pageURL, and compared normalized, links which cannot be parsed are left out
+func classifyLinks(pageURL, baseURL string, links []string) []classifiedLink {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil
+	}
+	classified := []classifiedLink{}
+	for _, l := range links {
+		u, err := base.Parse(strings.TrimSpace(l))
+		if err != nil {
+			continue
+		}
+		classified = append(classified, classifiedLink{url: l, class: classifyLink(page, u)})
+	}
+	return classified
+}
+
+// classifyLink returns the link class of the resolved link u on page
+// links with another scheme than http and https, e.g. mailto:, leave the site and are cross-site
+func classifyLink(page, u *url.URL) string {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return linkCrossSite
+	}
+	if normalizeURL(u) == normalizeURL(page) {
+		return linkSamePage
+	}
+	if registeredDomain(u) == registeredDomain(page) {
+		return linkSameSite
+	}
+	return linkCrossSite
+}
+
+// registeredDomain returns the public suffix plus one label of the host of u, e.g. example.co.uk
+// hosts without one, like ip addresses and localhost, are returned as they are
+func registeredDomain(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// writeLinkClasses prints the number of links per class followed by up to maxExamples links
+func writeLinkClasses(w io.Writer, classified []classifiedLink, maxExamples int) {
+	fmt.Fprintln(w, "Links by site:")
+	for _, c := range linkClasses {
+		links := []string{}
+		for _, l := range classified {
+			if l.class == c {
+				links = append(links, l.url)
+			}
+		}
+		fmt.Fprintf(w, "%d - %s\n", len(links), c)
+		writeExamples(w, "  ", links, maxExamples)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestClassifyLinks(t *testing.T) {
+	doc := loadFixture(t, "link_classes.html")
+	got := classifyLinks("https://www.example.com/docs/page", "https://www.example.com/docs/page", getURLs(doc))
+	want := []classifiedLink{
+		{"#top", linkSamePage},
+		{"https://WWW.example.com:443/docs/page#install", linkSamePage},
+		{"/about", linkSameSite},
+		{"https://blog.example.com/post", linkSameSite},
+		{"https://example.co.uk/", linkCrossSite},
+		{"https://alice.github.io/", linkCrossSite},
+		{"mailto:team@example.com", linkCrossSite},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	var out bytes.Buffer
+	writeLinkClasses(&out, got, 1)
+	expected := "Links by site:\n" +
+		"2 - same-page\n  - #top\n  ... and 1 more\n" +
+		"2 - same-site\n  - /about\n  ... and 1 more\n" +
+		"3 - cross-site\n  - https://example.co.uk/\n  ... and 2 more\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRegisteredDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.example.com/":   "example.com",
+		"https://shop.example.co.uk": "example.co.uk",
+		//github.io is a private public suffix, its subdomains are different sites
+		"https://alice.github.io/": "alice.github.io",
+		"http://127.0.0.1:8080/":   "127.0.0.1",
+		"http://localhost/":        "localhost",
+	}
+	for raw, want := range tests {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := registeredDomain(u); got != want {
+			t.Errorf("%s: expected %s, got %s", raw, want, got)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// linkStatus is the outcome of pinging a link
+type linkStatus int
+
+const (
+	//linkNotChecked links were never requested because the deadline was reached first
+	linkNotChecked linkStatus = iota
+	linkOK
+	//linkDown links returned an error or an error status code
+	linkDown
+	//linkTimeout links were in flight when the deadline was reached
+	linkTimeout
+)
+
+func (s linkStatus) String() string {
+	switch s {
+	case linkOK:
+		return "OK"
+	case linkDown:
+		return "Down"
+	case linkTimeout:
+		return "Timeout"
+	}
+	return "NotChecked"
+}
+
+// linkResult contains the outcome of pinging one link
+type linkResult struct {
+	url    string
+	status linkStatus
+	code   int
+	err    error
+	//duration is the time until the response headers arrived
+	duration time.Duration
+	//timing is only complete with measureTiming, otherwise the body is not read
+	timing requestTiming
+	//slowRetry is the duration of the second request made because duration exceeded the slow threshold, 0 if there was none
+	slowRetry time.Duration
+	//consistentlySlow links exceeded the slow threshold with both requests
+	consistentlySlow bool
+}
+
+// linkChecker pings links in parallel
+type linkChecker struct {
+	workers int
+	//retries is the number of retries per link, as long as the shared budget allows
+	retries int
+	budget  *retryBudget
+	//filter decides which links are pinged at all
+	filter *urlFilter
+	//events receives a LinkChecked event per link if not nil
+	events chan<- event
+	//control pauses the dispatch of links if not nil
+	control *controller
+	//slowThreshold requests a successful link a second time if it took longer, 0 disables it, see --timeout-retry-on-slow
+	slowThreshold time.Duration
+	//known are results of a baseline which are reused instead of pinging the link again, see --since
+	known map[string]linkResult
+}
+
+// retryBudget limits the total number of retries of a run, it is safe for concurrent use
+type retryBudget struct {
+	mu   sync.Mutex
+	max  int //negative means unlimited
+	used int
+}
+
+func newRetryBudget(max int) *retryBudget {
+	return &retryBudget{max: max}
+}
+
+// take uses one retry and returns false if the budget is exhausted
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.max >= 0 && b.used >= b.max {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// consumed returns the number of retries used so far
+func (b *retryBudget) consumed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// check pings links with a pool of workers until all links are checked or ctx is done
+// results are in the same order as links
+func (c *linkChecker) check(ctx context.Context, links []string) []linkResult {
+	workers := c.workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]linkResult, len(links))
+	//known links are merged into the results and not dispatched
+	pending := []int{}
+	for i, l := range links {
+		if k, ok := c.known[l]; ok {
+			results[i] = k
+			continue
+		}
+		results[i] = linkResult{url: l, status: linkNotChecked}
+		pending = append(pending, i)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				//a job handed out right at the deadline is not started, while paused it waits for resume
+				if c.control.wait(ctx) != nil {
+					continue
+				}
+				results[i] = c.ping(ctx, links[i])
+				c.control.linkChecked()
+				e := event{Type: eventLinkChecked, URL: links[i], Status: results[i].code, Result: results[i].status.String()}
+				if results[i].err != nil {
+					e.Error = results[i].err.Error()
+				}
+				emit(c.events, e)
+			}
+		}()
+	}
+
+dispatch:
+	for _, i := range pending {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// ping requests link and retries failures while retries and budget allow
+func (c *linkChecker) ping(ctx context.Context, link string) linkResult {
+	r := pingLink(ctx, link)
+	for i := 0; i < c.retries && retryable(r) && ctx.Err() == nil; i++ {
+		if c.budget != nil && !c.budget.take() {
+			break
+		}
+		r = pingLink(ctx, link)
+	}
+	//the slow retry measures latency and does not use the retry budget
+	if c.slowThreshold > 0 && r.status == linkOK && r.duration > c.slowThreshold && ctx.Err() == nil {
+		again := pingLink(ctx, link)
+		r.slowRetry = again.duration
+		r.consistentlySlow = again.status == linkOK && again.duration > c.slowThreshold
+	}
+	return r
+}
+
+// retryable returns true for errors and server side status codes which may be temporary
+func retryable(r linkResult) bool {
+	if r.status != linkDown {
+		return false
+	}
+	return r.err != nil || r.code >= http.StatusInternalServerError || r.code == http.StatusTooManyRequests
+}
+
+// pingLink requests link and reports whether it is accessible
+func pingLink(ctx context.Context, link string) linkResult {
+	r := linkResult{url: link}
+	start := time.Now()
+	res, err := getWithContext(ctx, link)
+	r.duration = time.Since(start)
+	if err != nil {
+		r.err = err
+		if ctx.Err() != nil {
+			r.status = linkTimeout
+		} else {
+			r.status = linkDown
+		}
+		return r
+	}
+	if measureTiming {
+		io.Copy(ioutil.Discard, res.Body)
+	}
+	res.Body.Close()
+	r.timing = responseTiming(res)
+
+	r.code = res.StatusCode
+	if res.StatusCode >= http.StatusBadRequest {
+		r.status = linkDown
+	} else {
+		r.status = linkOK
+	}
+	return r
+}
+
+// countStatus returns the number of results with status s
+func countStatus(results []linkResult, s linkStatus) int {
+	n := 0
+	for _, r := range results {
+		if r.status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// consistentlySlowLinks returns the links which were slow on both requests, with their durations
+func consistentlySlowLinks(results []linkResult) []string {
+	slow := []string{}
+	for _, r := range results {
+		if r.consistentlySlow {
+			slow = append(slow, fmt.Sprintf("%s (%s, %s)", r.url, r.duration.Round(time.Millisecond), r.slowRetry.Round(time.Millisecond)))
+		}
+	}
+	return slow
+}
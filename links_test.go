@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckLinksDeadline(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	links := []string{ts.URL + "/down", ts.URL + "/down"}
+	for i := 0; i < 10; i++ {
+		links = append(links, ts.URL+"/slow")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results := (&linkChecker{workers: 2}).check(ctx, links)
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("expected checkLinks to stop at the deadline, took %s", d)
+	}
+
+	//both workers check the down links, then hang on a slow link each until the deadline
+	if n := countStatus(results, linkDown); n != 2 {
+		t.Errorf("expected 2 down links, got %d", n)
+	}
+	if n := countStatus(results, linkTimeout); n != 2 {
+		t.Errorf("expected 2 timed out links, got %d", n)
+	}
+	if n := countStatus(results, linkNotChecked); n != 8 {
+		t.Errorf("expected 8 links not checked, got %d", n)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	links := []string{}
+	for i := 0; i < 10; i++ {
+		links = append(links, ts.URL)
+	}
+	c := &linkChecker{workers: 3, retries: 3, budget: newRetryBudget(5)}
+	results := c.check(context.Background(), links)
+
+	if n := countStatus(results, linkDown); n != 10 {
+		t.Errorf("expected 10 down links, got %d", n)
+	}
+	//10 first attempts and 5 retries, instead of 30 retries without a budget
+	if n := atomic.LoadInt32(&requests); n != 15 {
+		t.Errorf("expected 15 requests, got %d", n)
+	}
+	if n := c.budget.consumed(); n != 5 {
+		t.Errorf("expected 5 retries consumed, got %d", n)
+	}
+}
+
+func TestRetryNotFound(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	c := &linkChecker{workers: 1, retries: 3, budget: newRetryBudget(-1)}
+	c.check(context.Background(), []string{ts.URL})
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d requests", n)
+	}
+}
+
+func TestRetryOnSlow(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+	})
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &linkChecker{workers: 1, slowThreshold: 20 * time.Millisecond}
+	results := c.check(context.Background(), []string{ts.URL + "/slow", ts.URL + "/fast"})
+	slow, fast := results[0], results[1]
+	if slow.status != linkOK || slow.duration < 50*time.Millisecond || slow.slowRetry < 50*time.Millisecond || !slow.consistentlySlow {
+		t.Errorf("expected two slow measurements and the consistently slow flag, got %+v", slow)
+	}
+	if fast.slowRetry != 0 || fast.consistentlySlow {
+		t.Errorf("expected the fast link to be requested once, got %+v", fast)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+	if got := consistentlySlowLinks(results); len(got) != 1 || !strings.HasPrefix(got[0], ts.URL+"/slow (") {
+		t.Errorf("expected only the slow link, got %v", got)
+	}
+}
+
+func TestBaseElement(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/section/page":         "testdata/base.html",
+		"/docs/v2/intro.html":   "testdata/plain.html",
+		"/docs/v1/changes.html": "testdata/plain.html",
+		"/about":                "testdata/plain.html",
+		"/section/intro.html":   "testdata/plain.html",
+	})
+	defer ts.Close()
+
+	fr, err := analyzePage(context.Background(), ts.URL+"/section/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fr.base != ts.URL+"/docs/v2/" {
+		t.Fatalf("expected the first <base href> resolved against the page, got %q", fr.base)
+	}
+	r := sortLinks(context.Background(), fr.urls, fr.linkBase(), &linkChecker{workers: 1})
+	got := []string{}
+	for _, l := range r.links {
+		got = append(got, l.url)
+	}
+	want := []string{ts.URL + "/docs/v2/intro.html", ts.URL + "/docs/v1/changes.html", ts.URL + "/about"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the links to resolve against the base href %v, got %v", want, got)
+	}
+	if r.inaccessible != 0 {
+		t.Errorf("expected no inaccessible links, got %+v", r.links)
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); fr.base != "" {
+		t.Errorf("expected no base without a <base> element, got %q", fr.base)
+	}
+}
+
+func TestDeprecatedProtocolLinks(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/":      "testdata/deprecated_protocols.html",
+		"/about": "testdata/plain.html",
+	})
+	defer ts.Close()
+
+	fr, err := analyzePage(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ftp://ftp.example.com/pub/release.tar.gz", "FILE:///C:/shared/handbook.pdf"}
+	if !reflect.DeepEqual(fr.deprecatedProtocolLinks, want) {
+		t.Errorf("expected %v, got %v", want, fr.deprecatedProtocolLinks)
+	}
+	if !hasFinding(fr, "DeprecatedProtocolLinks") {
+		t.Errorf("expected a DeprecatedProtocolLinks finding, got %v", fr.findings())
+	}
+	//only the http link is checked, the others are external
+	r := sortLinks(context.Background(), fr.urls, fr.linkBase(), &linkChecker{workers: 1})
+	if len(r.links) != 1 || r.links[0].url != ts.URL+"/about" || r.externals != 4 {
+		t.Errorf("expected only /about to be checked and 4 external links, got %+v", r)
+	}
+	if fr := fetch(loadFixture(t, "plain.html")); hasFinding(fr, "DeprecatedProtocolLinks") {
+		t.Error("expected no DeprecatedProtocolLinks finding for a plain page")
+	}
+}
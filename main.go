@@ -1,73 +1,620 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 //fetchResult contains information found on website
 type fetchResult struct {
+	url      string
 	version  string
 	title    string
 	headings map[string]int
 	urls     []string
+	toc      []tocEntry
+	//base is the resolved <base href> of the page, links resolve against it instead of url, see linkBase
+	base string
+	//malformedLinks are the urls with a path like /a//b, with the reason
+	malformedLinks []string
+	//deprecatedProtocolLinks are the links with a scheme like ftp: or file:, see deprecatedSchemes
+	deprecatedProtocolLinks []string
+	//downloads are the urls of links to files like pdf or zip by type
+	downloads map[string][]string
+	//missingDoctype distinguishes a page without doctype from an unknown doctype, for both version is empty
+	missingDoctype bool
+	xhtmlNamespace bool
+	//skipped are the analyzers disabled by --checks, their fields have zero values
+	skipped map[string]bool
+
+	wordCount   int
+	thinContent bool
+
+	//set from the response by analyzeDocument
+	crawlable               bool
+	crawlReason             string
+	cache                   cacheInfo
+	upgradeInsecureRequests upgradeInsecure
+	//timing of the request of the page, its body was read by parsePage
+	timing requestTiming
+	//redirects are the hops before url, which is the url after redirects
+	redirects []redirectHop
+	//insecureHops are the http urls of the redirect chain after an https url
+	insecureHops []string
+	//missingSRI are cross-origin scripts and stylesheets without Subresource Integrity
+	missingSRI []string
+	//formsWithoutCSRFToken are POST forms without a hidden token field, see getFormsWithoutCSRFToken
+	formsWithoutCSRFToken []string
+	//etag and lastModified are the validators of the response, see checkConditionalGet
+	etag           string
+	lastModified   string
+	conditionalGet conditionalGet
+	//settle is the diff with a second fetch after --settle
+	settle settleResult
+
+	renderBlockingCSS int
+	estimatedRequests int
+	resourceHints     map[string][]string
+	preloadsMissingAs []string
+	fonts             []string
+	trackingPixels    []string
+	excessiveFonts    bool
+	structuredData    structuredData
+	//hosts are contacted for the subresources and links, thirdPartyHosts are of another site
+	hosts           []string
+	thirdPartyHosts []string
+
+	comments            int
+	conditionalComments int
+	//presentationalAttributes counts the elements by obsolete presentational attribute like bgcolor
+	presentationalAttributes map[string]int
+
+	h1Texts    []string
+	missingH1  bool
+	multipleH1 bool
+
+	hasManifest      bool
+	manifestURL      string
+	hasServiceWorker bool
+
+	canonical              string
+	ogURL                  string
+	canonicalOgURLMismatch bool
+	rawURLAnchors          []string
+	placeholderLinks       int
+	pathDepth              int
+	deepURL                bool
+	paginationNext         string
+	paginationPrev         string
+	metaDescription        string
+	hasMetaDescription     bool
+	metaDescriptionIssue   string
+	//canonicalTargets are the pages analyzed by --resolve-canonical, canonicalLoop their loop ("a -> b -> a")
+	//canonicalUnreachable is the target which could not be analyzed with its error
+	canonicalTargets     []*fetchResult
+	canonicalLoop        string
+	canonicalUnreachable string
+
+	interactiveControls map[string]int
+	mainLandmarks       int
+	danglingLabels      []string
+	sharedLabelTargets  []string
+	autoplayMedia       []string
+	ariaRoles           map[string]int
+	invalidAriaRoles    []string
+	lowContrast         []string
+	duplicateAccesskeys []string
+	svgIssues           []string
+	nestedInteractive   []string
+	missingAlt          []string
+	missingSkipLink     bool
+	autofocus           []string
+	skippedHeadings     []string
+	unlabeledFields     []string
+
+	//hasViewport is false without a viewport meta, viewportIssues are the problems of its content
+	hasViewport    bool
+	viewportIssues []string
+	zoomDisabled   []string
+	wideElements   []string
 }
 
 type sortResult struct {
 	internals    int
+	externals    int
 	inaccessible int
+	timeouts     int
+	notChecked   int
 	login        bool
+	retriesUsed  int
+	links        []linkResult
+	//classified are the discovered links with their link class, only set with --classify-links
+	classified []classifiedLink
+}
+
+// userAgent identifies the app in requests, robots.txt and robots meta tags
+const userAgent = "go-web"
+
+// client is used for all requests, its Transport is replaced when replaying a HAR file or using proxies
+var client = &http.Client{}
+
+// requestHeaders are sent with every request, see setUserAgent
+var requestHeaders = http.Header{"User-Agent": {userAgent}, "Upgrade-Insecure-Requests": {"1"}}
+
+// get sends a GET request with requestHeaders
+func get(url string) (*http.Response, error) {
+	return getWithContext(context.Background(), url)
 }
 
-//parsePage returns *goquery documents
-func parsePage(url string) (*goquery.Document, error) {
-	res, err := http.Get(url)
+// getWithContext is get, cancelled when ctx is done
+func getWithContext(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	return doRequest(ctx, req)
+}
+
+//doRequest sends req with the requestHeaders after the requestJitter and requestThrottle
+func doRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if totalBytes != nil && totalBytes.exceeded() {
+		return nil, errMaxTotalBytes
+	}
+	for k, v := range requestHeaders {
+		req.Header[k] = v
+	}
+	if requestJitter != nil {
+		if err := requestJitter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if requestThrottle != nil {
+		if err := requestThrottle.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+	}
+	req, body := traceTiming(req)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if requestThrottle != nil {
+		requestThrottle.observe(res.Request.URL.Host, res)
+	}
+	body.ReadCloser = res.Body
+	res.Body = body
+	return res, nil
+}
+
+//parsePage returns *goquery documents and the response, whose body is already closed
+func parsePage(ctx context.Context, url string) (*goquery.Document, *http.Response, error) {
+	res, err := getWithContext(ctx, url)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer res.Body.Close()
 
 	//check status code
 	if res.StatusCode != http.StatusOK {
-		log.Fatalf("Error response status code was %d", res.StatusCode)
+		return nil, res, fmt.Errorf("Error response status code was %d", res.StatusCode)
 	}
 
 	//create a goquery document from the HTTP response
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatal("Error loading HTTP response body ", err)
+		return nil, res, fmt.Errorf("Error loading HTTP response body %v", err)
 	}
-	return doc, nil
+	//relative urls found in the document are resolved against the url after redirects
+	doc.Url = res.Request.URL
+	return doc, res, nil
 }
 
-func main() {
-	inputURL := os.Args[1]
-	if inputURL == "" {
-		log.Fatalln("missing url")
+// redirectHop is a requested url which redirected with status
+type redirectHop struct {
+	url    string
+	status int
+}
+
+// redirectChain returns the redirects which led to res, starting at the requested url
+func redirectChain(res *http.Response) []redirectHop {
+	hops := []redirectHop{}
+	for r := res.Request.Response; r != nil; r = r.Request.Response {
+		hops = append([]redirectHop{{url: r.Request.URL.String(), status: r.StatusCode}}, hops...)
 	}
+	return hops
+}
 
-	doc, err := parsePage(inputURL)
-	if err != nil || doc == nil {
-		return
+// exit codes of run
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	//exitMaxRuntime means --max-runtime was reached and the results are partial
+	exitMaxRuntime = 3
+	//exitMaxTotalBytes means --max-total-bytes was exceeded and the results are partial
+	exitMaxTotalBytes = 4
+)
+
+// analyzePage fetches url and collects the fetchResult, including header based checks
+func analyzePage(ctx context.Context, url string) (*fetchResult, error) {
+	doc, res, err := parsePage(ctx, url)
+	if err != nil {
+		return nil, err
 	}
+	return analyzeDocument(ctx, doc, res), nil
+}
+
+// analyzeDocument collects the fetchResult of a fetched page
+func analyzeDocument(ctx context.Context, doc *goquery.Document, res *http.Response) *fetchResult {
 	//collect fetchResult from site
 	fresult := fetch(doc)
+	fresult.redirects = redirectChain(res)
+	fresult.timing = responseTiming(res)
+	for _, a := range responseAnalyzers {
+		if checkEnabled(a.name) {
+			a.analyze(ctx, doc, res, fresult)
+		}
+	}
+	return fresult
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run analyzes the url given in args, writes the results to stdout and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseOptions(args, stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	limits = thresholds{minWords: opts.minWords, maxFonts: opts.maxFonts, maxPathDepth: opts.maxPathDepth}
+	enabledChecks, _ = parseChecks(opts.checks)
+	collapseTrailingSlash = opts.normalizeTrailingSlash
+	measureTiming = opts.ttfb
+	canonicalJSON = opts.canonicalJSON
+
+	if err := setUserAgent(opts.uaProfile, opts.userAgent); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	if opts.jitter > 0 {
+		seed := opts.jitterSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		requestJitter = newJitter(opts.jitter, seed)
+	}
+	if opts.adaptiveRate {
+		requestThrottle = newThrottle()
+	}
+
+	if opts.harFile != "" {
+		t, err := loadHAR(opts.harFile)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		client.Transport = t
+	}
+	if len(opts.proxies) > 0 {
+		client.Transport = newProxyTransport(opts.proxies)
+	}
+
+	//events are written by their own goroutine until run returns
+	var events chan event
+	if opts.eventsFile != "" {
+		w := stderr
+		if opts.eventsFile != "-" {
+			f, err := os.Create(opts.eventsFile)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitError
+			}
+			defer f.Close()
+			w = f
+		}
+		events = make(chan event)
+		done := make(chan struct{})
+		go func() {
+			if err := writeNDJSON(w, events); err != nil {
+				fmt.Fprintln(stderr, "Error writing events", err)
+			}
+			close(done)
+		}()
+		defer func() {
+			close(events)
+			<-done
+		}()
+	}
+
+	//the control plane pauses and resumes the dispatch of pages and links until run returns
+	var control *controller
+	if opts.controlAddr != "" {
+		l, err := net.Listen("tcp", opts.controlAddr)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		control = &controller{}
+		srv := &http.Server{Handler: control.handler()}
+		go srv.Serve(l)
+		defer srv.Close()
+		fmt.Fprintf(stderr, "control plane listening on http://%s\n", l.Addr())
+	}
+
+	//ctx is cancelled when the max runtime is reached, cancelling in-flight requests
+	ctx := context.Background()
+	if opts.maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.maxRuntime)
+		defer cancel()
+	}
+	//pageCtx is not cancelled by the cap, the page exceeding it is still read and reported
+	pageCtx := ctx
+	//ctx is also cancelled when the downloaded bytes exceed the cap
+	totalBytes = nil
+	if opts.maxTotalBytes > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		totalBytes = newByteBudget(opts.maxTotalBytes, cancel)
+	}
+	//partial reports the max runtime or the byte cap and returns its exit code
+	partial := func() int {
+		if totalBytes != nil && totalBytes.exceeded() {
+			fmt.Fprintf(stderr, "max total bytes of %d reached after %d bytes, results are partial\n", opts.maxTotalBytes, totalBytes.consumed())
+			return exitMaxTotalBytes
+		}
+		fmt.Fprintf(stderr, "max runtime of %s reached, results are partial\n", opts.maxRuntime)
+		return exitMaxRuntime
+	}
+
+	if opts.grpcListen != "" {
+		l, err := net.Listen("tcp", opts.grpcListen)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "grpc server listening on %s\n", l.Addr())
+		newChecker := func() *linkChecker {
+			return &linkChecker{workers: opts.workers, retries: opts.retries, budget: newRetryBudget(opts.retryBudget), slowThreshold: opts.retryOnSlow, filter: opts.filter}
+		}
+		if err := serveGRPC(ctx, l, newChecker); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	if opts.search != "" {
+		searchURL, links, err := searchSite(ctx, opts.searchTemplate, opts.site, opts.search)
+		if err != nil {
+			if ctx.Err() != nil {
+				return partial()
+			}
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "Searched %s\nfound %d result links to %s:\n", searchURL, len(links), opts.site)
+		for _, l := range links {
+			fmt.Fprintln(stdout, l)
+		}
+		return exitOK
+	}
+
+	if opts.inputCSV != "" {
+		f, err := os.Open(opts.inputCSV)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		defer f.Close()
+		checker := &linkChecker{workers: opts.workers, retries: opts.retries, budget: newRetryBudget(opts.retryBudget), filter: opts.filter, control: control, events: events}
+		if err := auditCSV(ctx, f, stdout, opts.urlColumn, checker); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		if ctx.Err() != nil {
+			return partial()
+		}
+		return exitOK
+	}
+
+	if opts.crawl {
+		c := &crawler{maxPages: opts.maxPages, maxDepth: opts.maxDepth, filter: opts.filter, followPagination: opts.followPagination, control: control, events: events}
+		var previous []string
+		if opts.previousCrawl != "" {
+			if previous, err = loadCrawlBaseline(opts.previousCrawl); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitError
+			}
+		}
+		pages, err := c.crawl(ctx, opts.url)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		var diff *crawlDiff
+		if previous != nil {
+			diff = diffCrawls(previous, pages)
+		}
+		switch opts.format {
+		case "edges-csv":
+			if err := writeEdgesCSV(stdout, pages); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitError
+			}
+		case "mermaid":
+			if err := writeMermaid(stdout, pages); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitError
+			}
+		case "json":
+			if err := writeCrawlJSON(stdout, pages, opts.dedupeOutput, diff); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitError
+			}
+		default:
+			displayCrawl(stdout, pages, opts.maxExamples, opts.dedupeOutput)
+			if diff != nil {
+				writeCrawlDiff(stdout, diff, opts.maxExamples)
+			}
+		}
+		if ctx.Err() != nil {
+			return partial()
+		}
+		return exitOK
+	}
+
+	if opts.compare != "" {
+		diffs, err := comparePages(ctx, opts.url, opts.compare)
+		if err != nil {
+			if ctx.Err() != nil {
+				return partial()
+			}
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		displayDiffs(stdout, opts.url, opts.compare, diffs)
+		return exitOK
+	}
+
+	rules := scoreRules
+	if opts.scoreWeights != "" {
+		if rules, err = loadScoreWeights(opts.scoreWeights); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	}
+
+	analyze := analyzePage
+	if opts.streaming {
+		analyze = streamAnalyzePage
+	}
+	if opts.settle > 0 {
+		//the document is kept to diff it with the second fetch
+		analyze = func(ctx context.Context, url string) (*fetchResult, error) {
+			doc, res, err := parsePage(ctx, url)
+			if err != nil {
+				return nil, err
+			}
+			fr := analyzeDocument(ctx, doc, res)
+			fr.settle = checkSettle(ctx, doc, opts.settle)
+			return fr, nil
+		}
+	}
+	fresult, err := analyze(pageCtx, opts.url)
+	if err != nil {
+		if ctx.Err() != nil {
+			return partial()
+		}
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	linkCtx := ctx
+	if opts.deadline > 0 {
+		var cancel context.CancelFunc
+		linkCtx, cancel = context.WithTimeout(ctx, opts.deadline)
+		defer cancel()
+	}
 
 	//sort urls
-	sresult := sortLinks(fresult.urls, inputURL)
+	checker := &linkChecker{workers: opts.workers, retries: opts.retries, budget: newRetryBudget(opts.retryBudget), slowThreshold: opts.retryOnSlow, filter: opts.filter, control: control, events: events}
+	if opts.since != "" {
+		known, err := loadBaseline(opts.since)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		checker.known = known
+	}
+	//links are resolved against the <base href> or the url after redirects
+	sresult := sortLinks(linkCtx, fresult.urls, fresult.linkBase(), checker)
+
+	if opts.classifyLinks {
+		sresult.classified = classifyLinks(fresult.url, fresult.linkBase(), fresult.urls)
+	}
+
+	if opts.resolveCanonical {
+		fresult.canonicalTargets, fresult.canonicalLoop, fresult.canonicalUnreachable = resolveCanonical(ctx, fresult)
+	}
+
+	if opts.conditionalGet {
+		fresult.conditionalGet = checkConditionalGet(ctx, fresult)
+	}
 
-	display(fresult, sresult)
+	switch opts.format {
+	case "junit":
+		if err := writeJUnit(stdout, opts.url, fresult, sresult); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	case "sarif":
+		if err := writeSARIF(stdout, opts.url, fresult, sresult); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	case "scorecard":
+		if err := writeScorecard(stdout, fresult, rules); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	case "json":
+		if err := writeJSON(stdout, fresult, sresult); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	default:
+		if opts.profile != "" {
+			fmt.Fprintf(stdout, "%s of %s\n", profiles[opts.profile].title, fresult.url)
+		}
+		display(stdout, fresult, sresult, opts.maxExamples)
+		for _, c := range fresult.canonicalTargets {
+			fmt.Fprintf(stdout, "\nCanonical target %s:\n", c.url)
+			displayPage(stdout, c, opts.maxExamples)
+		}
+		if opts.conditionalGet {
+			fmt.Fprintf(stdout, "Conditional GET: %s\n", fresult.conditionalGet)
+		}
+		if opts.settle > 0 {
+			fmt.Fprintf(stdout, "Settle: %s\n", fresult.settle)
+		}
+		if opts.ttfb {
+			fmt.Fprintf(stdout, "Page timing: %s\n", fresult.timing)
+			writeLinkTimings(stdout, sresult.links, opts.maxExamples)
+		}
+		if opts.toc {
+			writeTOC(stdout, fresult.toc)
+		}
+	}
+	if ctx.Err() != nil {
+		return partial()
+	}
+	return exitOK
 }
 
-//sortLinks finds subsets of links
-func sortLinks(fresult []string, inputURL string) *sortResult {
+//sortLinks finds subsets of links, internal links are checked by workers until ctx is done
+func sortLinks(ctx context.Context, fresult []string, inputURL string, checker *linkChecker) *sortResult {
 	r := &sortResult{}
 
 	parsed, err := url.Parse(inputURL)
@@ -78,22 +625,36 @@ func sortLinks(fresult []string, inputURL string) *sortResult {
 
 	// find internal links
 	findinternals := func(s string) bool {
-		return strings.HasPrefix(s, baseURL) || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "#")
+		if strings.HasPrefix(s, baseURL) || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "#") {
+			return true
+		}
+		//path relative links like page.html, which resolve against the <base href> or the page
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme == "" && u.Host == "" && u.Path != ""
 	}
 	internals := filter(fresult, findinternals)
 	r.internals = len(internals)
-	fmt.Printf("found %d internal links and %d\n", r.internals, len(fresult)-r.internals)
+	r.externals = len(fresult) - r.internals
 
-	//check if link is inaccessible //change Get(input) like in 2
-	pingLink := func(link string) bool {
-		_, err := http.Get(link)
+	//check if link is inaccessible, relative links are resolved against the input url
+	resolved := []string{}
+	for _, link := range internals {
+		u, err := parsed.Parse(link)
 		if err != nil {
-			return true
+			r.links = append(r.links, linkResult{url: link, status: linkDown, err: err})
+			continue
 		}
-		return false
+		if checker.filter.allowed(u.String()) {
+			resolved = append(resolved, u.String())
+		}
+	}
+	r.links = append(r.links, checker.check(ctx, resolved)...)
+	r.inaccessible = countStatus(r.links, linkDown)
+	r.timeouts = countStatus(r.links, linkTimeout)
+	r.notChecked = countStatus(r.links, linkNotChecked)
+	if checker.budget != nil {
+		r.retriesUsed = checker.budget.consumed()
 	}
-	inaccessible := filter(internals, pingLink)
-	fmt.Printf("found %d inaccessible links\n", len(inaccessible))
 
 	//check if internal links contain login (could be done with regex as well)
 	containsLoginByURL := func(il string) bool {
@@ -123,16 +684,23 @@ func filter(ss []string, f func(string) bool) (filtered []string) {
 //fetch finds elements on website and returns a fetchResult
 func fetch(doc *goquery.Document) *fetchResult {
 	fr := fetchResult{}
-
-	v, err := versionReader(doc)
-	if err != nil {
-		fmt.Println("Error loading version", err)
+	if doc.Url != nil {
+		fr.url = doc.Url.String()
 	}
-	fr.version = v
-	fr.title = doc.Find("title").Contents().Text()
-	fr.headings = getHeadings(doc)
-	fr.urls = getURLs(doc)
 
+	for _, a := range analyzers {
+		if !checkEnabled(a.name) {
+			fr.skip(a.name)
+			continue
+		}
+		a.analyze(doc, &fr)
+	}
+	//the responseAnalyzers are run by analyzeDocument
+	for _, a := range responseAnalyzers {
+		if !checkEnabled(a.name) {
+			fr.skip(a.name)
+		}
+	}
 	return &fr
 }
 
@@ -149,23 +717,136 @@ func getHeadings(doc *goquery.Document) map[string]int {
 	for i := 1; i <= 6; i++ {
 		str := strconv.Itoa(i)
 		doc.Find("h" + str).Each(func(i int, s *goquery.Selection) {
-			hs["h"+str] = +1
+			hs["h"+str]++
 		})
 	}
 	return hs
 }
 
+// getH1Texts returns the trimmed text of every h1, a page should have exactly one
+func getH1Texts(doc *goquery.Document) []string {
+	texts := []string{}
+	doc.Find("h1").Each(func(i int, s *goquery.Selection) {
+		texts = append(texts, strings.Join(strings.Fields(s.Text()), " "))
+	})
+	return texts
+}
+
 //getURLs finds all urls and returns slice of unique urls
 //the contains check could be removed if urls do not need to be unique
 func getURLs(doc *goquery.Document) []string {
-	foundUrls := []string{}
+	return collectURLs(doc).urls
+}
+
+// collectURLs returns the urlSet of the anchors of doc, with the links classified by scheme
+func collectURLs(doc *goquery.Document) *urlSet {
+	found := &urlSet{urls: []string{}}
 	doc.Find("a").Each(func(i int, s *goquery.Selection) {
 		u, _ := s.Attr("href")
-		if !contains(foundUrls, u) {
-			foundUrls = append(foundUrls, u)
+		found.add(u)
+	})
+	return found
+}
+
+// deprecatedSchemes are link schemes which are unusual on modern sites and often insecure
+// such links are external for sortLinks and never requested
+var deprecatedSchemes = []string{"ftp", "telnet", "gopher", "file"}
+
+// documentBase returns the url the relative urls of doc resolve against
+// it is the href of the first <base> resolved against doc.Url, or doc.Url if there is none; nil if neither is known
+func documentBase(doc *goquery.Document) *url.URL {
+	href, ok := doc.Find("base[href]").First().Attr("href")
+	if !ok {
+		return doc.Url
+	}
+	base, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return doc.Url
+	}
+	if doc.Url != nil {
+		base = doc.Url.ResolveReference(base)
+	}
+	return base
+}
+
+// linkBase returns the url the links of fr resolve against, its <base href> or its url
+func (fr *fetchResult) linkBase() string {
+	if fr.base != "" {
+		return fr.base
+	}
+	return fr.url
+}
+
+// urlSet collects the unique navigational urls of anchors in document order
+type urlSet struct {
+	urls []string
+	//keys are the urls with collapsed trailing slashes, if that is enabled
+	keys []string
+	//deprecated are the urls with one of the deprecatedSchemes
+	deprecated []string
+}
+
+// add adds href unless it is a placeholder or already in the set
+func (s *urlSet) add(href string) {
+	//placeholder links are no navigation, see placeholderHref
+	if placeholderHref(href) {
+		return
+	}
+	key := href
+	if p, err := url.Parse(href); err == nil && collapseTrailingSlash {
+		p.Path, p.RawPath = trimTrailingSlash(p.Path), trimTrailingSlash(p.RawPath)
+		key = p.String()
+	}
+	if !contains(s.keys, key) {
+		s.keys = append(s.keys, key)
+		s.urls = append(s.urls, href)
+		if u, err := url.Parse(strings.TrimSpace(href)); err == nil && contains(deprecatedSchemes, strings.ToLower(u.Scheme)) {
+			s.deprecated = append(s.deprecated, href)
+		}
+	}
+}
+
+// getResources finds subresources needed to render the page and returns slice of unique urls
+// these are scripts, stylesheets, images, iframes and preloaded fonts
+func getResources(doc *goquery.Document) []string {
+	resources := []string{}
+	add := func(u string) {
+		u = strings.TrimSpace(u)
+		if u != "" && !contains(resources, u) {
+			resources = append(resources, u)
 		}
+	}
+	doc.Find("script[src], img[src], iframe[src]").Each(func(i int, s *goquery.Selection) {
+		add(s.AttrOr("src", ""))
 	})
-	return foundUrls
+	doc.Find("link[href]").Each(func(i int, s *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		if contains(rel, "stylesheet") || (contains(rel, "preload") && strings.EqualFold(s.AttrOr("as", ""), "font")) {
+			add(s.AttrOr("href", ""))
+		}
+	})
+	return resources
+}
+
+// countRenderBlockingCSS counts stylesheets in head which are likely to block rendering
+// this is a heuristic: print/speech media, alternate stylesheets and preloads (rel=preload) are not counted
+func countRenderBlockingCSS(doc *goquery.Document) int {
+	n := 0
+	doc.Find("head link").Each(func(i int, s *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		if !contains(rel, "stylesheet") || contains(rel, "alternate") {
+			return
+		}
+		if _, ok := s.Attr("disabled"); ok {
+			return
+		}
+		switch strings.ToLower(strings.TrimSpace(s.AttrOr("media", ""))) {
+		case "print", "speech", "none":
+			return
+		}
+		n++
+	})
+	return n
 }
 
 //Contains returns true if slice already contains url
@@ -216,10 +897,142 @@ func searchForm(doc *goquery.Document) bool {
 }
 
 //displays results
-func display(fr *fetchResult, r *sortResult) {
-	fmt.Printf("Website title: %s \nHTML version: %s\nHeadings count by level:\n", fr.title, fr.version)
-	for k, v := range fr.headings {
-		fmt.Printf("%d - %s\n", v, k)
+// maxExamples limits the examples printed per list, 0 prints all
+func display(w io.Writer, fr *fetchResult, r *sortResult, maxExamples int) {
+	if fr.ran("links") {
+		fmt.Fprintf(w, "found %d internal links and %d\n", r.internals, r.externals)
+		fmt.Fprintf(w, "found %d inaccessible links\n", r.inaccessible)
+		inaccessible := []string{}
+		for _, l := range r.links {
+			if l.status == linkDown {
+				inaccessible = append(inaccessible, l.url)
+			}
+		}
+		writeExamples(w, "  ", inaccessible, maxExamples)
+		if r.timeouts > 0 || r.notChecked > 0 {
+			fmt.Fprintf(w, "deadline reached: %d links timed out, %d links not checked\n", r.timeouts, r.notChecked)
+		}
+		if slow := consistentlySlowLinks(r.links); len(slow) > 0 {
+			fmt.Fprintf(w, "found %d consistently slow links\n", len(slow))
+			writeExamples(w, "  ", slow, maxExamples)
+		}
+		if r.retriesUsed > 0 {
+			fmt.Fprintf(w, "used %d retries of the retry budget\n", r.retriesUsed)
+		}
+		fmt.Fprintf(w, "Contains login is: %t\n", r.login)
+		if r.classified != nil {
+			writeLinkClasses(w, r.classified, maxExamples)
+		}
+	}
+	displayPage(w, fr, maxExamples)
+}
+
+// writeCounts prints counts sorted by key
+func writeCounts(w io.Writer, counts map[string]int) {
+	keys := []string{}
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%d - %s\n", counts[k], k)
+	}
+}
+
+// displayPage prints the information found on a page
+func displayPage(w io.Writer, fr *fetchResult, maxExamples int) {
+	if len(fr.redirects) > 0 {
+		fmt.Fprintln(w, "Redirect chain:")
+		for _, h := range fr.redirects {
+			fmt.Fprintf(w, "%s (%d)\n", h.url, h.status)
+		}
+		fmt.Fprintf(w, "Final URL: %s\n", fr.url)
+	}
+	//only the information of the analyzers which ran is printed
+	if fr.ran("meta") {
+		version := fr.version
+		if fr.missingDoctype {
+			version = "no doctype"
+		} else if version == "" {
+			version = "unknown doctype"
+		}
+		fmt.Fprintf(w, "Website title: %s \nHTML version: %s\n", fr.title, version)
+		if fr.hasMetaDescription {
+			fmt.Fprintf(w, "Meta description: %d characters\n", utf8.RuneCountInString(fr.metaDescription))
+		}
+	} else if fr.title != "" {
+		//streamPage extracts only the title of meta
+		fmt.Fprintf(w, "Website title: %s \n", fr.title)
+	}
+	if fr.ran("headings") {
+		fmt.Fprintln(w, "Headings count by level:")
+		for k, v := range fr.headings {
+			fmt.Fprintf(w, "%d - %s\n", v, k)
+		}
+	}
+	if fr.ran("links") {
+		if fr.base != "" {
+			fmt.Fprintf(w, "Base url of links: %s\n", fr.base)
+		}
+		writeDownloads(w, fr.downloads, maxExamples)
+	}
+	if fr.ran("content") {
+		fmt.Fprintf(w, "Visible words: %d\n", fr.wordCount)
+	}
+	if fr.ran("cache") {
+		fmt.Fprintf(w, "Caching: %s\n", fr.cache)
+	}
+	if fr.ran("security") {
+		fmt.Fprintf(w, "Upgrade-Insecure-Requests: %s\n", fr.upgradeInsecureRequests)
+	}
+	if fr.ran("perf") {
+		fmt.Fprintf(w, "Render-blocking stylesheets: %d\n", fr.renderBlockingCSS)
+		fmt.Fprintf(w, "Estimated requests to render: %d\n", fr.estimatedRequests)
+		fmt.Fprintf(w, "Fonts: %d\n", len(fr.fonts))
+		for _, rel := range resourceHints {
+			if hrefs := fr.resourceHints[rel]; len(hrefs) > 0 {
+				fmt.Fprintf(w, "Resource hints %s: %d\n", rel, len(hrefs))
+				writeExamples(w, "  ", hrefs, maxExamples)
+			}
+		}
+	}
+	if fr.ran("privacy") {
+		fmt.Fprintf(w, "Distinct hosts: %d (%d third-party)\n", len(fr.hosts), len(fr.thirdPartyHosts))
+		writeExamples(w, "  ", fr.thirdPartyHosts, maxExamples)
+	}
+	if fr.ran("structured-data") {
+		fmt.Fprintf(w, "Structured data: %s\n", fr.structuredData)
+	}
+	if fr.ran("pwa") {
+		if fr.hasManifest {
+			fmt.Fprintf(w, "Web app manifest: %s\n", fr.manifestURL)
+		} else {
+			fmt.Fprintln(w, "Web app manifest: none")
+		}
+		fmt.Fprintf(w, "Registers service worker: %t\n", fr.hasServiceWorker)
+	}
+	if fr.ran("a11y") {
+		fmt.Fprintln(w, "Interactive controls by type:")
+		writeCounts(w, fr.interactiveControls)
+		fmt.Fprintln(w, "ARIA roles:")
+		writeCounts(w, fr.ariaRoles)
+	}
+	if fr.ran("legacy") {
+		fmt.Fprintf(w, "HTML comments: %d (%d conditional comments)\n", fr.comments, fr.conditionalComments)
+		if len(fr.presentationalAttributes) > 0 {
+			fmt.Fprintln(w, "Presentational attributes:")
+			writeCounts(w, fr.presentationalAttributes)
+		}
+	}
+	if fr.ran("robots") {
+		if fr.crawlable {
+			fmt.Fprintln(w, "Crawlable: true")
+		} else {
+			fmt.Fprintf(w, "Crawlable: false (%s)\n", fr.crawlReason)
+		}
+	}
+	for _, f := range fr.findings() {
+		fmt.Fprintf(w, "Warning: %s\n", f.message)
+		writeExamples(w, "  ", f.examples, maxExamples)
 	}
-	fmt.Printf("Contains login is: %t", r.login)
 }
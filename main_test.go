@@ -1,8 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -32,3 +39,146 @@ type MatcherMock struct {
 func (m MatcherMock) sort(p []byte) (int, error) {
 	return m.sortMock(p)
 }
+
+// loadFixture parses a html file from testdata
+func loadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// hasFinding returns true if fr has a finding of kind
+func hasFinding(fr *fetchResult, kind string) bool {
+	for _, f := range fr.findings() {
+		if f.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRenderBlockingCSS(t *testing.T) {
+	doc := loadFixture(t, "render_blocking.html")
+	if n := countRenderBlockingCSS(doc); n != 1 {
+		t.Fatalf("expected 1 render-blocking stylesheet, got %d", n)
+	}
+}
+
+func TestEstimatedRequests(t *testing.T) {
+	doc := loadFixture(t, "resources.html")
+	//main.css, body.woff2, app.js, logo.png, hero.jpg and the iframe, duplicates counted once
+	if n := fetch(doc).estimatedRequests; n != 6 {
+		t.Fatalf("expected 6 estimated requests, got %d: %v", n, getResources(doc))
+	}
+}
+
+func TestH1(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		texts      []string
+		missingH1  bool
+		multipleH1 bool
+	}{
+		{"h1_none.html", []string{}, true, false},
+		{"plain.html", []string{"Plain page"}, false, false},
+		{"h1_two.html", []string{"Site name", "Article title"}, false, true},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if !reflect.DeepEqual(fr.h1Texts, tt.texts) {
+			t.Errorf("%s: expected h1 texts %q, got %q", tt.fixture, tt.texts, fr.h1Texts)
+		}
+		if fr.missingH1 != tt.missingH1 || fr.multipleH1 != tt.multipleH1 {
+			t.Errorf("%s: expected missingH1 %t and multipleH1 %t, got %t and %t", tt.fixture, tt.missingH1, tt.multipleH1, fr.missingH1, fr.multipleH1)
+		}
+	}
+}
+
+func TestHeadingsCount(t *testing.T) {
+	hs := fetch(loadFixture(t, "h1_none.html")).headings
+	if hs["h1"] != 0 || hs["h2"] != 2 {
+		t.Fatalf("expected 0 h1 and 2 h2, got %v", hs)
+	}
+}
+
+func TestMaxRuntime(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><html><head><title>Slow links</title></head><body>
+			<a href="/slow/1">1</a><a href="/slow/2">2</a><a href="/slow/3">3</a></body></html>`))
+	})
+	mux.HandleFunc("/slow/", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	start := time.Now()
+	code := run([]string{"--max-runtime", "300ms", "--workers", "1", ts.URL}, &stdout, &stderr)
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("expected run to stop near the max runtime, took %s", d)
+	}
+	if code != exitMaxRuntime {
+		t.Fatalf("expected exit code %d, got %d: %s", exitMaxRuntime, code, stderr.String())
+	}
+	//the page itself was analyzed before the link checks were cancelled
+	out := stdout.String()
+	if !strings.Contains(out, "Website title: Slow links") {
+		t.Errorf("expected the partial output to contain the title, got:\n%s", out)
+	}
+	if !strings.Contains(out, "1 links timed out, 2 links not checked") {
+		t.Errorf("expected the partial output to summarize unchecked links, got:\n%s", out)
+	}
+	if !strings.Contains(stderr.String(), "max runtime of 300ms reached") {
+		t.Errorf("expected a max runtime note, got %q", stderr.String())
+	}
+}
+
+func TestRedirectedSeed(t *testing.T) {
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/guide":
+			w.Write([]byte(`<!DOCTYPE html><html><head><title>Guide</title></head><body>
+				<a href="/guide/install">Install</a></body></html>`))
+		case "/guide/install":
+			w.Write([]byte("ok"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer final.Close()
+	mux := http.NewServeMux()
+	mux.Handle("/s", http.RedirectHandler("/short/abc", http.StatusFound))
+	mux.Handle("/short/abc", http.RedirectHandler(final.URL+"/guide", http.StatusMovedPermanently))
+	short := httptest.NewServer(mux)
+	defer short.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{short.URL + "/s"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	want := "Redirect chain:\n" +
+		short.URL + "/s (302)\n" +
+		short.URL + "/short/abc (301)\n" +
+		"Final URL: " + final.URL + "/guide\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output to contain\n%s\ngot\n%s", want, out)
+	}
+	//the link is resolved against the final host, the short host does not know it
+	if !strings.Contains(out, "found 1 internal links and 0\nfound 0 inaccessible links\n") {
+		t.Errorf("expected the link to resolve against the final url, got\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxMobileWidth is the widest fixed width in px an element can have without risking horizontal scrolling on phones
+const maxMobileWidth = 480
+
+// inlineWidth matches width and min-width declarations in px of a style attribute
+var inlineWidth = regexp.MustCompile(`(?i)(?:^|[;\s])(?:min-)?width\s*:\s*(\d+(?:\.\d+)?)px`)
+
+// viewportDirective is a key=value pair of the viewport meta content, lowercased
+type viewportDirective struct {
+	key   string
+	value string
+}
+
+// parseViewport splits the viewport meta content into its directives, separated by commas or semicolons
+func parseViewport(content string) []viewportDirective {
+	directives := []viewportDirective{}
+	for _, d := range strings.FieldsFunc(content, func(r rune) bool { return r == ',' || r == ';' }) {
+		parts := strings.SplitN(d, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		directives = append(directives, viewportDirective{strings.ToLower(strings.TrimSpace(parts[0])), strings.ToLower(strings.TrimSpace(parts[1]))})
+	}
+	return directives
+}
+
+// viewportZoomDisabled returns the directives of the viewport meta which prevent zooming
+func viewportZoomDisabled(doc *goquery.Document) []string {
+	disabled := []string{}
+	content := doc.Find(`meta[name="viewport"]`).First().AttrOr("content", "")
+	for _, d := range parseViewport(content) {
+		switch d.key {
+		case "user-scalable":
+			if d.value == "no" || d.value == "0" {
+				disabled = append(disabled, d.key+"="+d.value)
+			}
+		case "maximum-scale":
+			if s, err := strconv.ParseFloat(d.value, 64); err == nil && s <= 1 {
+				disabled = append(disabled, d.key+"="+d.value)
+			}
+		}
+	}
+	return disabled
+}
+
+// getViewportIssues returns whether the page has a viewport meta and how its content deviates
+// from the responsive width=device-width, initial-scale=1, disabled zooming is left to viewportZoomDisabled
+func getViewportIssues(doc *goquery.Document) (bool, []string) {
+	meta := doc.Find(`meta[name="viewport"]`).First()
+	if meta.Length() == 0 {
+		return false, nil
+	}
+	issues := []string{}
+	var width, scale string
+	for _, d := range parseViewport(meta.AttrOr("content", "")) {
+		switch d.key {
+		case "width":
+			width = d.value
+		case "initial-scale":
+			scale = d.value
+		}
+	}
+	switch _, err := strconv.ParseFloat(strings.TrimSuffix(width, "px"), 64); {
+	case width == "":
+		issues = append(issues, "width is missing, should be device-width")
+	case err == nil:
+		issues = append(issues, "fixed width="+width+" ignores the device width")
+	case width != "device-width":
+		issues = append(issues, "invalid width="+width+", should be device-width")
+	}
+	if s, err := strconv.ParseFloat(scale, 64); scale == "" {
+		issues = append(issues, "initial-scale is missing, should be 1")
+	} else if err != nil || s != 1 {
+		issues = append(issues, "initial-scale="+scale+" should be 1")
+	}
+	return true, issues
+}
+
+// getWideElements lists the elements with an inline width wider than maxMobileWidth
+func getWideElements(doc *goquery.Document) []string {
+	wide := []string{}
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		style := s.AttrOr("style", "")
+		for _, m := range inlineWidth.FindAllStringSubmatch(style, -1) {
+			if w, _ := strconv.ParseFloat(m[1], 64); w > maxMobileWidth {
+				wide = append(wide, fmt.Sprintf("%s style=%q", goquery.NodeName(s), strings.TrimSpace(style)))
+				return
+			}
+		}
+	})
+	return wide
+}
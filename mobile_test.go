@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestViewportZoomDisabled(t *testing.T) {
+	fr := fetch(loadFixture(t, "viewport_no_zoom.html"))
+	if want := []string{"maximum-scale=1", "user-scalable=no"}; !reflect.DeepEqual(fr.zoomDisabled, want) {
+		t.Errorf("expected %v, got %v", want, fr.zoomDisabled)
+	}
+	if len(fr.wideElements) != 0 {
+		t.Errorf("expected max-width not to count as a fixed width, got %v", fr.wideElements)
+	}
+	if !hasFinding(fr, "MobileUsability") {
+		t.Errorf("expected a MobileUsability finding, got %v", fr.findings())
+	}
+}
+
+func TestWideElements(t *testing.T) {
+	fr := fetch(loadFixture(t, "wide_element.html"))
+	if want := []string{`table style="width:1200px"`}; !reflect.DeepEqual(fr.wideElements, want) {
+		t.Errorf("expected %v, got %v", want, fr.wideElements)
+	}
+	if len(fr.zoomDisabled) != 0 {
+		t.Errorf("expected zooming to be allowed, got %v", fr.zoomDisabled)
+	}
+	if !hasFinding(fr, "MobileUsability") {
+		t.Errorf("expected a MobileUsability finding, got %v", fr.findings())
+	}
+}
+
+func TestViewportIssues(t *testing.T) {
+	tests := []struct {
+		fixture string
+		has     bool
+		issues  []string
+	}{
+		{"viewport_ok.html", true, []string{}},
+		{"wide_element.html", true, []string{}},
+		{"plain.html", false, nil},
+		{"viewport_fixed_width.html", true, []string{"fixed width=1024 ignores the device width", "initial-scale is missing, should be 1"}},
+		//disabled zooming is reported as MobileUsability
+		{"viewport_bad_scale.html", true, []string{"initial-scale=0.5 should be 1"}},
+		{"viewport_no_zoom.html", true, []string{}},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if fr.hasViewport != tt.has || !reflect.DeepEqual(fr.viewportIssues, tt.issues) {
+			t.Errorf("%s: expected viewport %t with issues %v, got %t and %v", tt.fixture, tt.has, tt.issues, fr.hasViewport, fr.viewportIssues)
+		}
+		if hasFinding(fr, "ViewportIssues") != (len(tt.issues) > 0) {
+			t.Errorf("%s: expected ViewportIssues finding %t, got %v", tt.fixture, len(tt.issues) > 0, fr.findings())
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// options are the command line flags of run
+type options struct {
+	url string
+
+	harFile     string
+	workers     int
+	uaProfile   string
+	userAgent   string
+	retries     int
+	retryBudget int
+	crawl       bool
+	maxPages    int
+	maxDepth    int
+	filter      *urlFilter
+	format      string
+	deadline    time.Duration
+	maxRuntime  time.Duration
+	compare     string
+	eventsFile  string
+	jitter      time.Duration
+	jitterSeed  int64
+
+	resolveCanonical bool
+	search           string
+	site             string
+	searchTemplate   string
+	minWords         int
+	maxExamples      int
+	proxies          proxyList
+	inputCSV         string
+	urlColumn        string
+	dedupeOutput     bool
+	checks           string
+	followPagination bool
+	since            string
+	maxFonts         int
+	controlAddr      string
+
+	normalizeTrailingSlash bool
+	maxPathDepth           int
+	streaming              bool
+	toc                    bool
+	conditionalGet         bool
+	classifyLinks          bool
+	retryOnSlow            time.Duration
+	scoreWeights           string
+	ttfb                   bool
+	previousCrawl          string
+	adaptiveRate           bool
+	profile                string
+	settle                 time.Duration
+	maxTotalBytes          int64
+	canonicalJSON          bool
+	grpcListen             string
+}
+
+// thresholds configure checks of fetch, run sets them from the options
+type thresholds struct {
+	//minWords flags pages with less visible words as thin content, 0 disables it
+	minWords int
+	//maxFonts flags pages loading more font resources, 0 disables it
+	maxFonts int
+	//maxPathDepth flags urls with more path segments, 0 disables it
+	maxPathDepth int
+}
+
+var limits = thresholds{}
+
+// formats are the valid values of --format
+var formats = []string{"text", "json", "junit", "sarif", "scorecard", "edges-csv", "mermaid"}
+
+// crawlFormats are the formats supported in crawl mode, the others are for a single page
+// text and json are supported in both modes
+var crawlFormats = []string{"text", "json", "edges-csv", "mermaid"}
+
+// parseOptions parses args, errors and usage are written to stderr
+func parseOptions(args []string, stderr io.Writer) (*options, error) {
+	opts := &options{filter: &urlFilter{}}
+	fs := flag.NewFlagSet("go-web", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: go-web [flags] url")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.harFile, "har", "", "analyze the responses stored in a HAR `file` instead of fetching them")
+	fs.IntVar(&opts.workers, "workers", 10, "number of links checked in parallel")
+	fs.StringVar(&opts.uaProfile, "ua-profile", "", "send the User-Agent and Accept headers of a preset: "+strings.Join(uaProfileNames(), ", "))
+	fs.StringVar(&opts.userAgent, "user-agent", "", "User-Agent header, overrides the one of --ua-profile")
+	fs.IntVar(&opts.retries, "retries", 0, "number of retries per failed link")
+	fs.IntVar(&opts.retryBudget, "retry-budget", 50, "maximum number of retries across the whole run, negative means unlimited")
+	fs.BoolVar(&opts.crawl, "crawl", false, "crawl the internal pages reachable from url and report duplicate pages")
+	fs.IntVar(&opts.maxPages, "max-pages", 50, "maximum number of pages visited in crawl mode")
+	fs.IntVar(&opts.maxDepth, "max-depth", 3, "maximum link depth from url in crawl mode")
+	fs.Var(&opts.filter.include, "include-pattern", "only crawl and ping urls matching this `regex`, can be repeated")
+	fs.Var(&opts.filter.ignore, "ignore-pattern", "never crawl or ping urls matching this `regex`, can be repeated and wins over --include-pattern")
+	fs.StringVar(&opts.format, "format", "text", "output `format`: "+strings.Join(formats, ", "))
+	fs.DurationVar(&opts.deadline, "deadline", 0, "stop checking links after this `duration`, 0 means no deadline")
+	fs.DurationVar(&opts.maxRuntime, "max-runtime", 0, "stop the whole run after this `duration` and print partial results, 0 means no limit")
+	fs.StringVar(&opts.compare, "compare", "", "analyze url and this second `url` and print their differences")
+	fs.StringVar(&opts.eventsFile, "events", "", "write progress events as NDJSON to this `file`, - for stderr")
+	fs.DurationVar(&opts.jitter, "jitter", 0, "wait a random `duration` up to this before each request, 0 disables it")
+	fs.Int64Var(&opts.jitterSeed, "jitter-seed", 0, "seed of the random jitter for reproducible runs, 0 picks a random seed")
+	fs.BoolVar(&opts.resolveCanonical, "resolve-canonical", false, "also analyze the canonical url if it differs from the fetched page")
+	fs.StringVar(&opts.search, "search", "", "search this `term` on --site with --search-template instead of analyzing a url")
+	fs.StringVar(&opts.site, "site", "", "`host` searched with --search, only result links to it are reported")
+	fs.StringVar(&opts.searchTemplate, "search-template", defaultSearchTemplate, "search url `template`, {query} is replaced by the escaped query")
+	fs.IntVar(&opts.minWords, "min-words", 0, "flag pages with less than `N` visible words as thin content, 0 disables it")
+	fs.IntVar(&opts.maxExamples, "max-examples", 0, "print at most `N` examples per list in text output, 0 prints all; json always contains all")
+	fs.Var(&opts.proxies, "proxy", "send requests through this proxy `url`, can be repeated or a comma list to rotate between proxies per request")
+	fs.StringVar(&opts.inputCSV, "input-csv", "", "analyze the url of every row of this CSV `file` and print the rows with result columns appended")
+	fs.StringVar(&opts.urlColumn, "url-column", "url", "`name` of the --input-csv column containing the urls")
+	fs.BoolVar(&opts.dedupeOutput, "dedupe-output", false, "in crawl mode report each finding kind once with the number and a sample of affected pages")
+	fs.StringVar(&opts.checks, "checks", "all", "comma separated `list` of the analyzers to run, all or of: "+strings.Join(checkNames(), ", "))
+	fs.BoolVar(&opts.followPagination, "follow-pagination", false, "in crawl mode also follow <link rel=next> and rel=prev pagination")
+	fs.StringVar(&opts.since, "since", "", "reuse the OK links of this previous --format json report `file` and only check new or failed links")
+	fs.IntVar(&opts.maxFonts, "max-fonts", 4, "flag pages loading more than `N` font resources, 0 disables it")
+	fs.StringVar(&opts.controlAddr, "control", "", "serve POST /pause, POST /resume and GET /status on this `address` to control a running crawl or link check")
+	fs.BoolVar(&opts.normalizeTrailingSlash, "normalize-trailing-slash", false, "treat /path and /path/ as the same link and the same page when crawling, by default they are different")
+	fs.IntVar(&opts.maxPathDepth, "max-path-depth", 5, "flag page urls with more than `N` path segments, 0 disables it")
+	fs.BoolVar(&opts.streaming, "streaming", false, "extract only title, headings and links of the page with a streaming tokenizer, for very large pages")
+	fs.BoolVar(&opts.toc, "toc", false, "also print a table of contents with the anchor of every heading")
+	fs.BoolVar(&opts.conditionalGet, "conditional-get", false, "request the page again with its ETag and Last-Modified and check the server answers 304 Not Modified")
+	fs.BoolVar(&opts.classifyLinks, "classify-links", false, "classify every discovered link as same-page, same-site (same registered domain) or cross-site, the internal and external counts still compare the exact host")
+	fs.DurationVar(&opts.retryOnSlow, "timeout-retry-on-slow", 0, "request a successful link once more if it took longer than this `duration` and flag it if it is slow again, 0 disables it")
+	fs.StringVar(&opts.scoreWeights, "score-weights", "", "JSON `file` of finding kinds and the points they deduct, overriding the defaults of --format scorecard")
+	fs.BoolVar(&opts.ttfb, "ttfb", false, "report the time to first byte and the total duration of the page and of every checked link, whose bodies are downloaded for it")
+	fs.StringVar(&opts.previousCrawl, "previous-crawl", "", "in crawl mode report the urls added and removed since this previous --crawl --format json report `file`")
+	fs.BoolVar(&opts.adaptiveRate, "adaptive-rate", false, "slow down requests to hosts answering 429 or 503, honoring Retry-After, and speed up again when they recover")
+	fs.StringVar(&opts.profile, "profile", "", "run the analyzers of a preset `profile` for a focused audit: "+strings.Join(profileNames(), ", ")+"; --checks may narrow it")
+	fs.DurationVar(&opts.settle, "settle", 0, "fetch the page again after this `duration` and report whether its markup changed, 0 disables it")
+	fs.Int64Var(&opts.maxTotalBytes, "max-total-bytes", 0, "stop requesting pages and links once the bodies downloaded in the whole run exceed `N` bytes and print partial results, 0 means no limit")
+	fs.BoolVar(&opts.canonicalJSON, "canonical-json", false, "write --format json with sorted keys and sorted lists, except ordered ones like redirects, for diff-friendly reports tracked in git")
+	fs.StringVar(&opts.grpcListen, "grpc-listen", "", "serve the Analyzer service of analyze.proto on this `address` instead of analyzing a url, it returns the --format json report of the requested url")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.profile != "" {
+		explicit := false
+		fs.Visit(func(f *flag.Flag) {
+			explicit = explicit || f.Name == "checks"
+		})
+		checks, err := profileChecks(opts.profile, opts.checks, explicit)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return nil, err
+		}
+		opts.checks = checks
+	}
+	opts.url = fs.Arg(0)
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return nil, err
+	}
+	return opts, nil
+}
+
+// validate checks the combination of options
+func (opts *options) validate() error {
+	if opts.grpcListen != "" {
+		if opts.url != "" || opts.crawl || opts.compare != "" || opts.inputCSV != "" || opts.search != "" {
+			return errors.New("--grpc-listen analyzes the requested urls and can not be used with a url, --crawl, --compare, --input-csv or --search")
+		}
+	} else if opts.search != "" {
+		if opts.site == "" {
+			return errors.New("--search requires --site")
+		}
+	} else if opts.inputCSV != "" {
+		if opts.crawl || opts.compare != "" || opts.format != "text" {
+			return errors.New("--input-csv can not be used with --crawl, --compare or --format")
+		}
+	} else if opts.url == "" {
+		return errors.New("missing url")
+	}
+	if opts.harFile != "" && len(opts.proxies) > 0 {
+		return errors.New("--proxy can not be used with --har")
+	}
+	checks, err := parseChecks(opts.checks)
+	if err != nil {
+		return err
+	}
+	//the validators of the conditional request are read by the cache analyzer
+	if opts.conditionalGet && ((checks != nil && !checks["cache"]) || opts.streaming) {
+		return errors.New("--conditional-get requires the cache check and can not be used with --streaming")
+	}
+	if !contains(formats, opts.format) {
+		return fmt.Errorf("unknown format %q", opts.format)
+	}
+	if opts.dedupeOutput && !opts.crawl {
+		return errors.New("--dedupe-output requires --crawl")
+	}
+	if opts.since != "" && (opts.crawl || opts.compare != "" || opts.inputCSV != "" || opts.search != "") {
+		return errors.New("--since is only supported for a single page")
+	}
+	if opts.settle > 0 && (opts.streaming || opts.crawl || opts.compare != "" || opts.inputCSV != "" || opts.search != "") {
+		return errors.New("--settle is only supported for a single page without --streaming")
+	}
+	if opts.streaming && (opts.crawl || opts.compare != "" || opts.inputCSV != "" || opts.search != "") {
+		return errors.New("--streaming is only supported for a single page")
+	}
+	if opts.streaming && opts.toc {
+		return errors.New("--toc can not be used with --streaming, which does not collect heading anchors")
+	}
+	if opts.scoreWeights != "" && opts.format != "scorecard" {
+		return errors.New("--score-weights requires --format scorecard")
+	}
+	if opts.canonicalJSON && opts.format != "json" {
+		return errors.New("--canonical-json requires --format json")
+	}
+	if opts.previousCrawl != "" && !opts.crawl {
+		return errors.New("--previous-crawl requires --crawl")
+	}
+	if opts.followPagination && !opts.crawl {
+		return errors.New("--follow-pagination requires --crawl")
+	}
+	if opts.format != "text" && opts.format != "json" && opts.crawl != contains(crawlFormats, opts.format) {
+		return fmt.Errorf("format %q is not supported %s crawl mode", opts.format, map[bool]string{true: "in", false: "without"}[opts.crawl])
+	}
+	return nil
+}
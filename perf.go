@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// resourceHints are the link relations counted by getResourceHints
+var resourceHints = []string{"preconnect", "dns-prefetch", "preload", "prefetch"}
+
+// getResourceHints returns the hrefs of resource hint links by relation,
+// and the hrefs of preloads without the as attribute, which browsers ignore
+func getResourceHints(doc *goquery.Document) (hints map[string][]string, missingAs []string) {
+	hints = map[string][]string{}
+	missingAs = []string{}
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if !contains(resourceHints, rel) {
+				continue
+			}
+			hints[rel] = append(hints[rel], href)
+			if rel == "preload" && strings.TrimSpace(s.AttrOr("as", "")) == "" {
+				missingAs = append(missingAs, href)
+			}
+		}
+	})
+	return hints, missingAs
+}
+
+// fontFaceURL matches the first url() of the src of an @font-face rule, the others are fallback formats of the same font
+var fontFaceURL = regexp.MustCompile(`(?is)@font-face\s*{[^}]*?src\s*:[^}]*?url\(\s*['"]?([^'")]+)`)
+
+// getFonts returns the distinct font resources of the page: preloaded fonts, @font-face rules in <style>
+// and the families requested from Google Fonts, which serves one font per family
+func getFonts(doc *goquery.Document) []string {
+	fonts := []string{}
+	add := func(f string) {
+		if f != "" && !contains(fonts, f) {
+			fonts = append(fonts, f)
+		}
+	}
+	//urls are resolved so that a preloaded font and its @font-face rule count once
+	base := documentBase(doc)
+	addURL := func(href string) {
+		if base != nil {
+			if u, err := base.Parse(href); err == nil {
+				href = u.String()
+			}
+		}
+		add(href)
+	}
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		rels := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		if contains(rels, "preload") && strings.EqualFold(strings.TrimSpace(s.AttrOr("as", "")), "font") {
+			addURL(href)
+			return
+		}
+		if !contains(rels, "stylesheet") {
+			return
+		}
+		u, err := url.Parse(href)
+		if err != nil || !strings.EqualFold(u.Hostname(), "fonts.googleapis.com") {
+			return
+		}
+		//the query is split by hand, url.Query drops the css2 parameters containing ; like Roboto:wght@400;700
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			if !strings.HasPrefix(param, "family=") {
+				continue
+			}
+			family, err := url.QueryUnescape(strings.TrimPrefix(param, "family="))
+			if err != nil {
+				continue
+			}
+			//css2 requests one family per parameter, the old api separates them with |
+			for _, f := range strings.Split(family, "|") {
+				if name := strings.TrimSpace(strings.SplitN(f, ":", 2)[0]); name != "" {
+					add("Google Fonts " + name)
+				}
+			}
+		}
+	})
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		for _, m := range fontFaceURL.FindAllStringSubmatch(s.Text(), -1) {
+			addURL(strings.TrimSpace(m[1]))
+		}
+	})
+	return fonts
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResourceHints(t *testing.T) {
+	fr := fetch(loadFixture(t, "resource_hints.html"))
+	want := map[string][]string{
+		"preconnect":   {"https://fonts.gstatic.com", "https://cdn.example.com"},
+		"dns-prefetch": {"https://analytics.example.com"},
+		"preload":      {"/fonts/body.woff2", "/js/app.js"},
+		"prefetch":     {"/next-page.html"},
+	}
+	if !reflect.DeepEqual(fr.resourceHints, want) {
+		t.Errorf("expected %v, got %v", want, fr.resourceHints)
+	}
+	if !reflect.DeepEqual(fr.preloadsMissingAs, []string{"/js/app.js"}) {
+		t.Errorf("expected the preload of /js/app.js to miss as, got %v", fr.preloadsMissingAs)
+	}
+	if !hasFinding(fr, "PreloadMissingAs") {
+		t.Error("expected a PreloadMissingAs finding")
+	}
+}
+
+func TestResourceHintsMaxExamples(t *testing.T) {
+	var b bytes.Buffer
+	displayPage(&b, fetch(loadFixture(t, "resource_hints.html")), 1)
+	want := "Resource hints preconnect: 2\n" +
+		"  - https://fonts.gstatic.com\n" +
+		"  ... and 1 more\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("expected the hints capped by max examples\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+func TestResourceHintsJSON(t *testing.T) {
+	fr := fetch(loadFixture(t, "resource_hints.html"))
+	var b bytes.Buffer
+	if err := writeJSON(&b, fr, &sortResult{}); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		ResourceHints map[string][]string `json:"resourceHints"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rep.ResourceHints, fr.resourceHints) {
+		t.Errorf("expected all resource hints %v, got %v", fr.resourceHints, rep.ResourceHints)
+	}
+}
+
+func TestFonts(t *testing.T) {
+	defer func(orig thresholds) { limits = orig }(limits)
+	limits.maxFonts = 4
+
+	fr := fetch(loadFixture(t, "fonts.html"))
+	want := []string{"Google Fonts Roboto", "Google Fonts Open Sans", "Google Fonts Lato", "/fonts/brand.woff2", "/fonts/icons.woff2"}
+	if !reflect.DeepEqual(fr.fonts, want) {
+		t.Errorf("expected %v, got %v", want, fr.fonts)
+	}
+	if !hasFinding(fr, "ExcessiveFonts") {
+		t.Errorf("expected an ExcessiveFonts finding, got %v", fr.findings())
+	}
+
+	limits.maxFonts = 5
+	fr = fetch(loadFixture(t, "fonts.html"))
+	if hasFinding(fr, "ExcessiveFonts") {
+		t.Errorf("expected no ExcessiveFonts finding at the threshold")
+	}
+	//json lists the fonts without the finding
+	var b bytes.Buffer
+	if err := writeJSON(&b, fr, &sortResult{}); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Fonts []string `json:"fonts"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rep.Fonts, want) {
+		t.Errorf("expected the fonts %v in json, got %v", want, rep.Fonts)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// styleSize matches width and height declarations in px of a style attribute
+var styleSize = regexp.MustCompile(`(?i)(?:^|[;\s])(width|height)\s*:\s*(\d+)(?:px)?\s*(?:;|$)`)
+
+// getTrackingPixels returns the src of <img> elements sized 1x1 by attributes or inline style
+func getTrackingPixels(doc *goquery.Document) []string {
+	pixels := []string{}
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		size := map[string]string{
+			"width":  strings.TrimSuffix(strings.TrimSpace(s.AttrOr("width", "")), "px"),
+			"height": strings.TrimSuffix(strings.TrimSpace(s.AttrOr("height", "")), "px"),
+		}
+		//the style wins over the attributes, like in browsers
+		for _, m := range styleSize.FindAllStringSubmatch(s.AttrOr("style", ""), -1) {
+			size[strings.ToLower(m[1])] = m[2]
+		}
+		if size["width"] == "1" && size["height"] == "1" {
+			pixels = append(pixels, strings.TrimSpace(s.AttrOr("src", "")))
+		}
+	})
+	return pixels
+}
+
+// getHosts returns the distinct hosts of the subresources and then the links of the page, in the order of getResources and getURLs
+// and the third-party ones among them, whose registered domain differs from the page's
+// relative urls are resolved against the documentBase, the page's own host counts too
+func getHosts(doc *goquery.Document) (hosts []string, thirdParty []string) {
+	hosts, thirdParty = []string{}, []string{}
+	base := documentBase(doc)
+	for _, ref := range append(getResources(doc), getURLs(doc)...) {
+		u, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			continue
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		host := strings.ToLower(u.Host)
+		if (u.Scheme != "http" && u.Scheme != "https") || host == "" || contains(hosts, host) {
+			continue
+		}
+		hosts = append(hosts, host)
+		if doc.Url != nil && registeredDomain(u) != registeredDomain(doc.Url) {
+			thirdParty = append(thirdParty, host)
+		}
+	}
+	return hosts, thirdParty
+}
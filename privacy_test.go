@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestTrackingPixels(t *testing.T) {
+	fr := fetch(loadFixture(t, "tracking_pixels.html"))
+	want := []string{"https://tracker.example.com/p.gif?id=42", "https://ads.example.com/px"}
+	if !reflect.DeepEqual(fr.trackingPixels, want) {
+		t.Errorf("expected only the 1x1 images %v, got %v", want, fr.trackingPixels)
+	}
+	if !hasFinding(fr, "TrackingPixels") {
+		t.Errorf("expected a TrackingPixels finding, got %v", fr.findings())
+	}
+}
+
+func TestHosts(t *testing.T) {
+	doc := loadFixture(t, "hosts.html")
+	doc.Url, _ = url.Parse("https://www.example.com/")
+	fr := fetch(doc)
+	hosts := []string{"cdn.jsdelivr.net", "www.googletagmanager.com", "static.example.com", "www.youtube.com", "www.example.com", "blog.example.com"}
+	if !reflect.DeepEqual(fr.hosts, hosts) {
+		t.Errorf("expected %d distinct hosts %v, got %v", len(hosts), hosts, fr.hosts)
+	}
+	thirdParty := []string{"cdn.jsdelivr.net", "www.googletagmanager.com", "www.youtube.com"}
+	if !reflect.DeepEqual(fr.thirdPartyHosts, thirdParty) {
+		t.Errorf("expected third-party hosts %v, got %v", thirdParty, fr.thirdPartyHosts)
+	}
+}
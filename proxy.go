@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// proxyList is a repeatable flag of proxy urls, each value may also be a comma separated list
+type proxyList []*url.URL
+
+func (p *proxyList) String() string {
+	s := []string{}
+	for _, u := range *p {
+		s = append(s, u.String())
+	}
+	return strings.Join(s, ",")
+}
+
+// Set parses and adds the proxies of v, an invalid entry fails the flag parsing
+func (p *proxyList) Set(v string) error {
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		u, err := url.Parse(entry)
+		if err != nil {
+			return fmt.Errorf("invalid proxy %q: %v", entry, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", entry)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy %q: missing host", entry)
+		}
+		*p = append(*p, u)
+	}
+	return nil
+}
+
+// rotateProxies returns a http.Transport Proxy function which uses the proxies round-robin, one per request
+func rotateProxies(proxies []*url.URL) func(*http.Request) (*url.URL, error) {
+	var next uint64
+	return func(*http.Request) (*url.URL, error) {
+		i := atomic.AddUint64(&next, 1) - 1
+		return proxies[i%uint64(len(proxies))], nil
+	}
+}
+
+// newProxyTransport returns a transport sending every request through the next of proxies
+func newProxyTransport(proxies []*url.URL) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = rotateProxies(proxies)
+	return t
+}
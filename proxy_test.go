@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProxyRotation(t *testing.T) {
+	var mu sync.Mutex
+	hits := []string{}
+	stub := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, name+" "+r.URL.String())
+			mu.Unlock()
+			fmt.Fprint(w, "ok")
+		}))
+	}
+	a, b := stub("a"), stub("b")
+	defer a.Close()
+	defer b.Close()
+
+	var proxies proxyList
+	if err := proxies.Set(a.URL + "," + b.URL); err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Transport: newProxyTransport(proxies)}
+	for i := 1; i <= 4; i++ {
+		res, err := c.Get(fmt.Sprintf("http://example.com/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	want := []string{
+		"a http://example.com/1",
+		"b http://example.com/2",
+		"a http://example.com/3",
+		"b http://example.com/4",
+	}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("expected requests to alternate between proxies %v, got %v", want, hits)
+	}
+}
+
+func TestInvalidProxy(t *testing.T) {
+	for _, p := range []string{"ftp://proxy.example.com", "http://", "http://ok.example.com,%zz"} {
+		var proxies proxyList
+		if err := proxies.Set(p); err == nil {
+			t.Errorf("expected %q to be rejected, got %v", p, proxies)
+		}
+	}
+	if code := run([]string{"--proxy", "localhost:8080", "http://example.com"}, ioutil.Discard, ioutil.Discard); code != exitUsage {
+		t.Errorf("expected exit code %d for an invalid proxy, got %d", exitUsage, code)
+	}
+}
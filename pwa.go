@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// getManifest returns the href of <link rel="manifest">, if any
+func getManifest(doc *goquery.Document) (string, bool) {
+	href := ""
+	found := false
+	doc.Find("link[rel][href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if contains(strings.Fields(strings.ToLower(s.AttrOr("rel", ""))), "manifest") {
+			href, found = strings.TrimSpace(s.AttrOr("href", "")), true
+		}
+		return !found
+	})
+	return href, found
+}
+
+// registersServiceWorker returns true if an inline script calls navigator.serviceWorker.register
+// scripts loaded by src are not fetched
+func registersServiceWorker(doc *goquery.Document) bool {
+	found := false
+	doc.Find("script").Not("[src]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		found = strings.Contains(strings.Join(strings.Fields(s.Text()), ""), "serviceWorker.register(")
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestPWA(t *testing.T) {
+	fr := fetch(loadFixture(t, "pwa.html"))
+	if !fr.hasManifest || fr.manifestURL != "/manifest.webmanifest" {
+		t.Errorf("expected manifest /manifest.webmanifest, got %t %q", fr.hasManifest, fr.manifestURL)
+	}
+	if !fr.hasServiceWorker {
+		t.Error("expected a service worker registration")
+	}
+
+	fr = fetch(loadFixture(t, "plain.html"))
+	if fr.hasManifest || fr.manifestURL != "" || fr.hasServiceWorker {
+		t.Errorf("expected no manifest and no service worker, got %t %q %t", fr.hasManifest, fr.manifestURL, fr.hasServiceWorker)
+	}
+}
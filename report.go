@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// jsonReport is the --format json output of a page
+type jsonReport struct {
+	URL string `json:"url"`
+	//Redirects lead from the requested url to URL
+	Redirects []jsonRedirect `json:"redirects"`
+	//Timing is only set with --ttfb, like the timings of Links
+	Timing   *jsonTiming    `json:"timing,omitempty"`
+	Title    string         `json:"title"`
+	Version  string         `json:"version"`
+	Headings map[string]int `json:"headings"`
+	//MetaDescriptionLength counts the characters of the whitespace normalized MetaDescription
+	HasMetaDescription    bool   `json:"hasMetaDescription"`
+	MetaDescription       string `json:"metaDescription"`
+	MetaDescriptionLength int    `json:"metaDescriptionLength"`
+	//ConditionalGetSupported is set with --conditional-get if the page has validators and the request did not fail
+	ConditionalGetSupported *bool `json:"conditionalGetSupported,omitempty"`
+	//TOC contains the headings with their anchor, "" if they have none
+	TOC   []jsonTOCEntry `json:"toc"`
+	Links jsonLinks      `json:"links"`
+	//Base is the <base href> links resolve against instead of URL, HasBase is false without it
+	HasBase bool   `json:"hasBase"`
+	Base    string `json:"base,omitempty"`
+	//Hosts are the distinct hosts of subresources and links, ThirdPartyHosts those of other sites
+	Hosts           []string `json:"hosts"`
+	ThirdPartyHosts []string `json:"thirdPartyHosts"`
+	//ResourceHints are the hrefs of the resource hint links by relation, unlike the text output not capped by --max-examples
+	ResourceHints map[string][]string `json:"resourceHints"`
+	//Fonts are the distinct font resources, also below the --max-fonts threshold
+	Fonts []string `json:"fonts"`
+	//HasViewport is false for pages without a viewport meta, its issues are ViewportIssues findings
+	HasViewport bool `json:"hasViewport"`
+	//CanonicalTargets are the pages analyzed with --resolve-canonical, CanonicalLoop is set if they lead back
+	CanonicalTargets []jsonCanonicalTarget `json:"canonicalTargets,omitempty"`
+	CanonicalLoop    string                `json:"canonicalLoop,omitempty"`
+	//CanonicalUnreachable is the canonical target which could not be analyzed, with its error
+	CanonicalUnreachable string `json:"canonicalUnreachable,omitempty"`
+	//Downloads are the links to files like pdf or zip by type
+	Downloads map[string][]string `json:"downloads"`
+	Findings  []jsonFinding       `json:"findings"`
+}
+
+type jsonCanonicalTarget struct {
+	URL      string        `json:"url"`
+	Title    string        `json:"title"`
+	Findings []jsonFinding `json:"findings"`
+}
+
+type jsonTOCEntry struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"`
+}
+
+type jsonTiming struct {
+	TTFBMs  int64 `json:"ttfbMs"`
+	TotalMs int64 `json:"totalMs"`
+}
+
+func newJSONTiming(t requestTiming) *jsonTiming {
+	return &jsonTiming{TTFBMs: t.ttfb.Milliseconds(), TotalMs: t.total.Milliseconds()}
+}
+
+type jsonRedirect struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+type jsonLinks struct {
+	Internal     int        `json:"internal"`
+	External     int        `json:"external"`
+	Inaccessible int        `json:"inaccessible"`
+	Timeouts     int        `json:"timeouts"`
+	NotChecked   int        `json:"notChecked"`
+	Checked      []jsonLink `json:"checked"`
+	//Discovered are all links with their class, only set with --classify-links
+	Discovered []jsonClassifiedLink `json:"discovered,omitempty"`
+}
+
+type jsonClassifiedLink struct {
+	URL   string `json:"url"`
+	Class string `json:"class"`
+}
+
+type jsonLink struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	//TimingsMs are the durations of both requests of a link retried by --timeout-retry-on-slow
+	TimingsMs        []int64     `json:"timingsMs,omitempty"`
+	ConsistentlySlow bool        `json:"consistentlySlow,omitempty"`
+	Timing           *jsonTiming `json:"timing,omitempty"`
+}
+
+// jsonFinding always contains all examples, unlike the text output
+type jsonFinding struct {
+	Kind     string   `json:"kind"`
+	Message  string   `json:"message"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// newJSONReport converts the results of a page
+func newJSONReport(fr *fetchResult, r *sortResult) jsonReport {
+	rep := jsonReport{
+		URL:             fr.url,
+		Title:           fr.title,
+		Version:         fr.version,
+		Headings:        fr.headings,
+		Downloads:       fr.downloads,
+		HasBase:         fr.base != "",
+		Hosts:           fr.hosts,
+		ThirdPartyHosts: fr.thirdPartyHosts,
+		Base:            fr.base,
+		HasViewport:     fr.hasViewport,
+		Links: jsonLinks{
+			Internal:     r.internals,
+			External:     r.externals,
+			Inaccessible: r.inaccessible,
+			Timeouts:     r.timeouts,
+			NotChecked:   r.notChecked,
+			Checked:      []jsonLink{},
+		},
+		Redirects: []jsonRedirect{},
+		Findings:  []jsonFinding{},
+	}
+	if c := fr.conditionalGet; c.checked && len(c.validators) > 0 && c.err == nil {
+		supported := c.supported()
+		rep.ConditionalGetSupported = &supported
+	}
+	if measureTiming {
+		rep.Timing = newJSONTiming(fr.timing)
+	}
+	rep.HasMetaDescription, rep.MetaDescription = fr.hasMetaDescription, fr.metaDescription
+	rep.MetaDescriptionLength = utf8.RuneCountInString(fr.metaDescription)
+	rep.CanonicalLoop = fr.canonicalLoop
+	rep.CanonicalUnreachable = fr.canonicalUnreachable
+	rep.ResourceHints = fr.resourceHints
+	rep.Fonts = fr.fonts
+	for _, c := range fr.canonicalTargets {
+		jc := jsonCanonicalTarget{URL: c.url, Title: c.title, Findings: []jsonFinding{}}
+		for _, f := range c.findings() {
+			jc.Findings = append(jc.Findings, jsonFinding{Kind: f.kind, Message: f.message, Examples: f.examples})
+		}
+		rep.CanonicalTargets = append(rep.CanonicalTargets, jc)
+	}
+	rep.TOC = []jsonTOCEntry{}
+	for _, e := range fr.toc {
+		rep.TOC = append(rep.TOC, jsonTOCEntry{Level: e.level, Text: e.text, Anchor: e.anchor})
+	}
+	for _, h := range fr.redirects {
+		rep.Redirects = append(rep.Redirects, jsonRedirect{URL: h.url, Status: h.status})
+	}
+	for _, l := range r.links {
+		jl := jsonLink{URL: l.url, Status: l.status.String(), Code: l.code}
+		if l.err != nil {
+			jl.Error = l.err.Error()
+		}
+		if measureTiming && l.code != 0 {
+			jl.Timing = newJSONTiming(l.timing)
+		}
+		if l.slowRetry > 0 {
+			jl.TimingsMs = []int64{l.duration.Milliseconds(), l.slowRetry.Milliseconds()}
+			jl.ConsistentlySlow = l.consistentlySlow
+		}
+		rep.Links.Checked = append(rep.Links.Checked, jl)
+	}
+	for _, l := range r.classified {
+		rep.Links.Discovered = append(rep.Links.Discovered, jsonClassifiedLink{URL: l.url, Class: l.class})
+	}
+	for _, f := range fr.findings() {
+		rep.Findings = append(rep.Findings, jsonFinding{Kind: f.kind, Message: f.message, Examples: f.examples})
+	}
+	return rep
+}
+
+// writeJSON writes the indented jsonReport of a page
+func writeJSON(w io.Writer, fr *fetchResult, r *sortResult) error {
+	return encodeReport(w, newJSONReport(fr, r))
+}
+
+// canonicalJSON makes the json reports diff-friendly, run sets it from --canonical-json
+var canonicalJSON bool
+
+// orderedJSONKeys are the arrays whose order has a meaning, like the hops of a redirect chain
+// canonicalReport keeps them and the arrays in them as they are
+var orderedJSONKeys = map[string]bool{"redirects": true, "toc": true, "pagination": true, "timingsMs": true}
+
+// encodeReport writes the indented json of a report, canonicalized with canonicalJSON
+func encodeReport(w io.Writer, rep interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if !canonicalJSON {
+		return enc.Encode(rep)
+	}
+	b, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	//numbers are decoded as json.Number to keep them as they are
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	return enc.Encode(canonicalReport(tree))
+}
+
+// canonicalReport sorts the arrays of a decoded json value by the json of their elements, except orderedJSONKeys
+// objects are maps, which encoding/json writes with sorted keys
+func canonicalReport(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if !orderedJSONKeys[k] {
+				v[k] = canonicalReport(e)
+			}
+		}
+	case []interface{}:
+		keys := make([]string, len(v))
+		for i, e := range v {
+			v[i] = canonicalReport(e)
+			b, _ := json.Marshal(v[i])
+			keys[i] = string(b)
+		}
+		sort.Sort(byKey{v, keys})
+	}
+	return v
+}
+
+// byKey sorts values by their keys
+type byKey struct {
+	values []interface{}
+	keys   []string
+}
+
+func (b byKey) Len() int           { return len(b.values) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// jsonCrawlReport is the --format json output of a crawl
+type jsonCrawlReport struct {
+	Pages []jsonCrawlPage `json:"pages"`
+	//Findings aggregates the findings of all pages with --dedupe-output, Pages have none then
+	Findings    []jsonFindingGroup `json:"findings,omitempty"`
+	BrokenLinks []jsonBrokenLink   `json:"brokenLinks"`
+	//BrokenTargets attribute the BrokenLinks to their targets, BrokenLinksByPage to their source pages
+	BrokenTargets     []jsonBrokenTarget `json:"brokenTargets"`
+	BrokenLinksByPage []jsonBrokenPage   `json:"brokenLinksByPage"`
+	Pagination        [][]string         `json:"pagination"`
+	Duplicates        [][]string         `json:"duplicates"`
+	NearDuplicates    [][]string         `json:"nearDuplicates"`
+	//Added and Removed are the urls which changed since the --previous-crawl
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type jsonCrawlPage struct {
+	URL      string        `json:"url"`
+	Depth    int           `json:"depth"`
+	Status   int           `json:"status,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Title    string        `json:"title,omitempty"`
+	Findings []jsonFinding `json:"findings,omitempty"`
+}
+
+// jsonFindingGroup contains the number of affected pages and a sample of their urls
+type jsonFindingGroup struct {
+	Kind    string   `json:"kind"`
+	Message string   `json:"message"`
+	Count   int      `json:"count"`
+	URLs    []string `json:"urls"`
+}
+
+type jsonBrokenLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type jsonBrokenTarget struct {
+	Target  string   `json:"target"`
+	Status  int      `json:"status,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Sources []string `json:"sources"`
+}
+
+type jsonBrokenPage struct {
+	Source  string   `json:"source"`
+	Targets []string `json:"targets"`
+}
+
+// writeCrawlJSON writes the indented jsonCrawlReport of pages, with the diff to a previous crawl if it is not nil
+func writeCrawlJSON(w io.Writer, pages []*crawlPage, dedupe bool, diff *crawlDiff) error {
+	rep := jsonCrawlReport{Pages: []jsonCrawlPage{}, BrokenLinks: []jsonBrokenLink{}}
+	for _, p := range pages {
+		jp := jsonCrawlPage{URL: p.url, Depth: p.depth, Status: p.status}
+		if p.err != nil {
+			jp.Error = p.err.Error()
+		}
+		if p.result != nil {
+			jp.Title = p.result.title
+			if !dedupe {
+				for _, f := range p.result.findings() {
+					jp.Findings = append(jp.Findings, jsonFinding{Kind: f.kind, Message: f.message, Examples: f.examples})
+				}
+			}
+		}
+		rep.Pages = append(rep.Pages, jp)
+	}
+	if dedupe {
+		rep.Findings = []jsonFindingGroup{}
+		for _, g := range groupFindings(pages) {
+			urls := g.urls
+			if len(urls) > dedupeSample {
+				urls = urls[:dedupeSample]
+			}
+			rep.Findings = append(rep.Findings, jsonFindingGroup{Kind: g.kind, Message: g.message, Count: len(g.urls), URLs: urls})
+		}
+	}
+	broken := brokenInternalLinks(pages)
+	for _, b := range broken {
+		jb := jsonBrokenLink{Source: b.source, Target: b.target, Status: b.status}
+		if b.err != nil {
+			jb.Error = b.err.Error()
+		}
+		rep.BrokenLinks = append(rep.BrokenLinks, jb)
+	}
+	rep.BrokenTargets = []jsonBrokenTarget{}
+	for _, t := range brokenTargets(broken) {
+		jt := jsonBrokenTarget{Target: t.target, Status: t.status, Sources: t.sources}
+		if t.err != nil {
+			jt.Error = t.err.Error()
+		}
+		rep.BrokenTargets = append(rep.BrokenTargets, jt)
+	}
+	rep.BrokenLinksByPage = []jsonBrokenPage{}
+	for _, p := range brokenLinksByPage(broken) {
+		jp := jsonBrokenPage{Source: p.source, Targets: []string{}}
+		for _, b := range p.links {
+			jp.Targets = append(jp.Targets, b.target)
+		}
+		rep.BrokenLinksByPage = append(rep.BrokenLinksByPage, jp)
+	}
+	rep.Pagination = paginationChains(pages)
+	rep.Duplicates, rep.NearDuplicates = duplicateGroups(pages)
+	if rep.Duplicates == nil {
+		rep.Duplicates = [][]string{}
+	}
+	if rep.NearDuplicates == nil {
+		rep.NearDuplicates = [][]string{}
+	}
+	if diff != nil {
+		rep.Added, rep.Removed = diff.added, diff.removed
+	}
+
+	return encodeReport(w, rep)
+}
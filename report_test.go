@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMaxExamples(t *testing.T) {
+	ts := fixtureServer(map[string]string{"/": "testdata/many_raw_urls.html"})
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--max-examples", "2", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	want := "Warning: 7 links use the bare url as text\n" +
+		"  - https://one.example.com\n" +
+		"  - https://two.example.com\n" +
+		"  ... and 5 more\n"
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("expected text output to contain\n%s\ngot\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"--max-examples", "2", "--format", "json", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	if rep.Title != "Link list" {
+		t.Errorf("expected title 'Link list', got %q", rep.Title)
+	}
+	for _, f := range rep.Findings {
+		if f.Kind == "RawURLAnchors" {
+			if len(f.Examples) != 7 {
+				t.Errorf("expected all 7 examples in JSON, got %v", f.Examples)
+			}
+			return
+		}
+	}
+	t.Errorf("expected a RawURLAnchors finding in JSON, got %+v", rep.Findings)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	ts := siteServer()
+	defer ts.Close()
+	defer func() { canonicalJSON = false }()
+
+	outputs := []string{}
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--canonical-json", "--format", "json", ts.URL + "/"}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+		}
+		outputs = append(outputs, stdout.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Fatalf("expected byte-identical runs, got\n%s\nand\n%s", outputs[0], outputs[1])
+	}
+
+	//the keys of the top-level object are sorted
+	dec := json.NewDecoder(strings.NewReader(outputs[0]))
+	dec.Token()
+	keys := []string{}
+	for dec.More() {
+		k, _ := dec.Token()
+		keys = append(keys, k.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !sort.StringsAreSorted(keys) || len(keys) == 0 {
+		t.Errorf("expected sorted keys, got %v", keys)
+	}
+}
+
+func TestCanonicalReport(t *testing.T) {
+	defer func() { canonicalJSON = false }()
+	canonicalJSON = true
+	reports := []jsonReport{
+		{
+			URL:       "https://example.com/",
+			Redirects: []jsonRedirect{{URL: "https://example.com/old", Status: 301}, {URL: "http://example.com/", Status: 301}},
+			Headings:  map[string]int{"h1": 1, "h2": 3},
+			Hosts:     []string{"example.com", "cdn.example.net"},
+			Links:     jsonLinks{Checked: []jsonLink{{URL: "/b", Status: "ok"}, {URL: "/a", Status: "ok"}}},
+		},
+		{
+			URL:       "https://example.com/",
+			Redirects: []jsonRedirect{{URL: "https://example.com/old", Status: 301}, {URL: "http://example.com/", Status: 301}},
+			Headings:  map[string]int{"h2": 3, "h1": 1},
+			Hosts:     []string{"cdn.example.net", "example.com"},
+			Links:     jsonLinks{Checked: []jsonLink{{URL: "/a", Status: "ok"}, {URL: "/b", Status: "ok"}}},
+		},
+	}
+	outputs := []string{}
+	for _, rep := range reports {
+		var b bytes.Buffer
+		if err := encodeReport(&b, rep); err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, b.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Fatalf("expected reordered reports to be identical, got\n%s\nand\n%s", outputs[0], outputs[1])
+	}
+	var rep jsonReport
+	if err := json.Unmarshal([]byte(outputs[0]), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Hosts[0] != "cdn.example.net" || rep.Links.Checked[0].URL != "/a" {
+		t.Errorf("expected sorted lists, got hosts %v and links %+v", rep.Hosts, rep.Links.Checked)
+	}
+	//the redirect chain keeps its order
+	if rep.Redirects[0].URL != "https://example.com/old" {
+		t.Errorf("expected the redirects in their order, got %+v", rep.Redirects)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// robotsRule is a single Allow or Disallow line of a robots.txt group
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+// robots contains the rules of robots.txt which apply to userAgent
+type robots struct {
+	rules []robotsRule
+}
+
+// robotsCache contains the robots.txt rules by scheme and host, so a crawl fetches them once per host
+var robotsCache = struct {
+	sync.Mutex
+	hosts map[string]*robots
+}{hosts: map[string]*robots{}}
+
+// fetchRobots loads robots.txt of the host of u, or returns the cached rules
+// a missing or unreadable robots.txt allows everything
+func fetchRobots(ctx context.Context, u *url.URL) *robots {
+	key := u.Scheme + "://" + u.Host
+	robotsCache.Lock()
+	r, ok := robotsCache.hosts[key]
+	robotsCache.Unlock()
+	if ok {
+		return r
+	}
+	r = loadRobots(ctx, key)
+	if ctx.Err() != nil {
+		return r
+	}
+	robotsCache.Lock()
+	robotsCache.hosts[key] = r
+	robotsCache.Unlock()
+	return r
+}
+
+// loadRobots requests robots.txt of origin
+func loadRobots(ctx context.Context, origin string) *robots {
+	res, err := getWithContext(ctx, origin+"/robots.txt")
+	if err != nil {
+		return &robots{}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return &robots{}
+	}
+	return parseRobots(res.Body, userAgent)
+}
+
+// parseRobots returns the rules of the group matching agent, or of the "*" group if there is none
+// a group matches if its user-agent is the product token of agent, ignoring case as RFC 9309 asks
+func parseRobots(r io.Reader, agent string) *robots {
+	agent = productToken(agent)
+	var mine, any []robotsRule
+	foundMine := false
+
+	//a group starts with one or more user-agent lines followed by rules
+	var agents []string
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			//an empty user-agent names no agent, its rules apply to nobody
+			if value != "" {
+				agents = append(agents, productToken(value))
+			}
+		case "allow", "disallow":
+			inRules = true
+			//an empty disallow allows everything
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", path: value}
+			for _, a := range agents {
+				if a == "*" {
+					any = append(any, rule)
+				} else if a == agent {
+					mine = append(mine, rule)
+					foundMine = true
+				}
+			}
+		}
+	}
+	if foundMine {
+		return &robots{rules: mine}
+	}
+	return &robots{rules: any}
+}
+
+// productToken returns the lowercased name of a user agent without its version, "Googlebot/2.1" is googlebot
+func productToken(agent string) string {
+	agent = strings.ToLower(strings.TrimSpace(agent))
+	if i := strings.IndexAny(agent, "/ "); i >= 0 {
+		agent = agent[:i]
+	}
+	return agent
+}
+
+// allowed returns false if the longest matching rule for path is a Disallow
+// path is the request uri, including the query, so rules like /*?session= can match
+func (r *robots) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	best := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.path, path) {
+			continue
+		}
+		//on equal length, allow wins
+		if len(rule.path) > best || (len(rule.path) == best && rule.allow) {
+			best = len(rule.path)
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a robots.txt path pattern supporting the * wildcard and the $ end anchor
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, p := range parts[1:] {
+		i := strings.Index(rest, p)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(p):]
+	}
+	if anchored {
+		//a trailing * can consume the rest
+		return rest == "" || parts[len(parts)-1] == ""
+	}
+	return true
+}
+
+// checkCrawlable combines robots.txt, meta robots and X-Robots-Tag
+// if the page is not crawlable, reason explains which of them block it
+func checkCrawlable(ctx context.Context, pageURL *url.URL, header http.Header, doc *goquery.Document) (bool, string) {
+	var reasons []string
+	if !fetchRobots(ctx, pageURL).allowed(pageURL.RequestURI()) {
+		reasons = append(reasons, "disallowed by robots.txt")
+	}
+	if metaNoindex(doc) {
+		reasons = append(reasons, "meta robots noindex")
+	}
+	if headerNoindex(header) {
+		reasons = append(reasons, "X-Robots-Tag noindex")
+	}
+	return len(reasons) == 0, strings.Join(reasons, "; ")
+}
+
+// metaNoindex checks <meta name="robots"> and <meta name="go-web"> for noindex
+func metaNoindex(doc *goquery.Document) bool {
+	noindex := false
+	doc.Find("meta[name]").Each(func(i int, s *goquery.Selection) {
+		name := strings.ToLower(s.AttrOr("name", ""))
+		if name == "robots" || name == userAgent {
+			noindex = noindex || hasNoindex(s.AttrOr("content", ""))
+		}
+	})
+	return noindex
+}
+
+// headerNoindex checks X-Robots-Tag headers, which may be limited to one agent with an "agent:" prefix
+func headerNoindex(header http.Header) bool {
+	for _, v := range header.Values("X-Robots-Tag") {
+		if i := strings.Index(v, ":"); i >= 0 {
+			prefix := strings.TrimSpace(v[:i])
+			if !strings.ContainsAny(prefix, " ,") && !strings.EqualFold(prefix, "unavailable_after") {
+				if !strings.EqualFold(prefix, userAgent) {
+					continue
+				}
+				v = v[i+1:]
+			}
+		}
+		if hasNoindex(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNoindex returns true if a comma separated list of robots directives contains noindex or none
+func hasNoindex(directives string) bool {
+	for _, d := range strings.Split(directives, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "noindex" || d == "none" {
+			return true
+		}
+	}
+	return false
+}
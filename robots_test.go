@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// crawlServer serves robots.txt, testdata fixtures and a page sending X-Robots-Tag
+func crawlServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /\n\nUser-agent: go-web\nDisallow: /private\nDisallow: /*?session=\nAllow: /\n"))
+	})
+	mux.HandleFunc("/private/page", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	mux.HandleFunc("/noindex", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/noindex.html")
+	})
+	mux.HandleFunc("/header", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "go-web: noindex")
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	mux.HandleFunc("/other-agent", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "googlebot: noindex")
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCheckCrawlable(t *testing.T) {
+	ts := crawlServer()
+	defer ts.Close()
+
+	tests := []struct {
+		path      string
+		crawlable bool
+		reason    string
+	}{
+		{"/", true, ""},
+		{"/private/page", false, "disallowed by robots.txt"},
+		{"/noindex", false, "meta robots noindex"},
+		{"/header", false, "X-Robots-Tag noindex"},
+		{"/other-agent", true, ""},
+		{"/?session=abc", false, "disallowed by robots.txt"},
+		{"/?page=2", true, ""},
+	}
+	for _, tt := range tests {
+		doc, res, err := parsePage(context.Background(), ts.URL+tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crawlable, reason := checkCrawlable(context.Background(), res.Request.URL, res.Header, doc)
+		if crawlable != tt.crawlable || reason != tt.reason {
+			t.Errorf("%s: expected (%t, %q), got (%t, %q)", tt.path, tt.crawlable, tt.reason, crawlable, reason)
+		}
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	r := parseRobots(strings.NewReader("User-agent: *\nDisallow: /*.pdf$\nDisallow: /tmp/\nAllow: /tmp/public\n"), userAgent)
+	tests := map[string]bool{
+		"/":               true,
+		"/doc.pdf":        false,
+		"/doc.pdf?x=1":    true,
+		"/tmp/file":       false,
+		"/tmp/public/ok":  true,
+		"/other/tmp/file": true,
+	}
+	for path, want := range tests {
+		if got := r.allowed(path); got != want {
+			t.Errorf("%s: expected allowed %t, got %t", path, want, got)
+		}
+	}
+}
+
+func TestParseRobotsEmptyUserAgent(t *testing.T) {
+	r := parseRobots(strings.NewReader("User-agent:\nDisallow: /\n\nUser-agent: *\nDisallow: /tmp/\n"), userAgent)
+	if !r.allowed("/") || r.allowed("/tmp/file") {
+		t.Errorf("expected the group without user-agent to be ignored, got rules %+v", r.rules)
+	}
+}
+
+func TestParseRobotsProductToken(t *testing.T) {
+	txt := "User-agent: go\nDisallow: /go/\n\nUser-agent: GO-WEB-Images\nDisallow: /images/\n\nUser-agent: *\nDisallow: /tmp/\n"
+	//near misses of go-web fall back to the * group
+	r := parseRobots(strings.NewReader(txt), userAgent)
+	if !r.allowed("/go/page") || !r.allowed("/images/a.png") || r.allowed("/tmp/file") {
+		t.Errorf("expected only the * group to apply, got rules %+v", r.rules)
+	}
+	//the product token is compared ignoring case and version
+	r = parseRobots(strings.NewReader("User-agent: Go-Web/2.0\nDisallow: /private/\n\nUser-agent: *\nDisallow: /\n"), userAgent)
+	if r.allowed("/private/a") || !r.allowed("/public") {
+		t.Errorf("expected the go-web group to apply, got rules %+v", r.rules)
+	}
+}
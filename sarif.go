@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sarifLog is the subset of SARIF 2.1.0 read by code scanning dashboards
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// sarifLevels are the SARIF levels of finding kinds, the other kinds are warnings
+// the security findings and broken links are errors
+var sarifLevels = map[string]string{
+	"BrokenLink":              "error",
+	"InsecureRedirectHop":     "error",
+	"MissingSRI":              "error",
+	"PossibleCSRFMissing":     "error",
+	"DeprecatedProtocolLinks": "error",
+	"MissingDoctype":          "note",
+	"RawURLAnchors":           "note",
+	"PlaceholderLinks":        "note",
+	"ExcessiveFonts":          "note",
+	"TrackingPixels":          "note",
+}
+
+// sarifDescriptions are the short descriptions of the rules, the finding kinds and BrokenLink
+var sarifDescriptions = map[string]string{
+	"BrokenLink":                  "An internal link is inaccessible",
+	"MissingDoctype":              "The page has no doctype",
+	"MissingH1":                   "The page has no h1",
+	"MultipleH1":                  "The page has more than one h1",
+	"ThinContent":                 "The page has less visible words than --min-words",
+	"NotCrawlable":                "Search engines may not crawl or index the page",
+	"MetaDescriptionLength":       "The meta description is empty, too short or too long",
+	"MissingMetaDescription":      "The page has no meta description",
+	"CanonicalOgUrlMismatch":      "The canonical url and og:url differ",
+	"CanonicalTargets":            "The canonical url points to another page",
+	"CanonicalLoop":               "Canonical urls point to each other in a loop",
+	"CanonicalUnreachable":        "The canonical target can not be analyzed",
+	"RawURLAnchors":               "Links use their raw url as text",
+	"MalformedLinks":              "Links have hrefs which can not be parsed",
+	"DeepURLs":                    "The page url has more path segments than --max-path-depth",
+	"StructuredDataWithoutJSONLD": "Structured data uses microdata or RDFa but no JSON-LD",
+	"PlaceholderLinks":            "Anchors have placeholder hrefs which do not navigate",
+	"MainLandmarkIssue":           "The page does not have exactly one main landmark",
+	"DanglingLabel":               "Labels refer to missing ids",
+	"SharedLabelTarget":           "Ids are referenced by more than one label",
+	"InvalidAriaRoles":            "Role attributes contain values which are not ARIA roles",
+	"DuplicateAccesskeys":         "Accesskeys are assigned to more than one element",
+	"SvgAccessibilityIssues":      "Inline svgs have no accessible name or no role=img",
+	"NestedInteractive":           "Interactive elements are nested in links or buttons",
+	"SkippedHeadingLevels":        "Headings skip a heading level",
+	"UnlabeledFields":             "Form fields have no label",
+	"MissingAlt":                  "Images have no alt attribute",
+	"MissingSkipLink":             "The page has a navigation but no skip link",
+	"Autofocus":                   "Elements take the focus on load with autofocus",
+	"ContrastWarnings":            "Inline colors have low contrast",
+	"AutoplayMedia":               "Audio or video plays automatically",
+	"MobileUsability":             "The page disables zooming or has elements too wide for phones",
+	"ViewportIssues":              "The viewport meta is not responsive",
+	"RenderBlockingCSS":           "Stylesheets in the head block rendering",
+	"PreloadMissingAs":            "Preload hints have no as attribute",
+	"ExcessiveFonts":              "The page loads more fonts than --max-fonts",
+	"CacheHeaders":                "The response has no useful cache headers",
+	"ConditionalGetIgnored":       "The server ignores conditional requests",
+	"TrackingPixels":              "The page loads tracking pixels",
+	"DynamicContentDetected":      "The page content changes after it was loaded",
+	"InsecureRedirectHop":         "A redirect goes through plain http",
+	"PossibleCSRFMissing":         "POST forms have no hidden CSRF token field",
+	"MissingSRI":                  "Cross-origin scripts and stylesheets have no Subresource Integrity",
+	"DeprecatedProtocolLinks":     "Links use deprecated or risky protocols like ftp: or file:",
+	"ConditionalComments":         "The page contains conditional comments for old Internet Explorers",
+	"NamespaceWarning":            "The page declares the XHTML namespace",
+	"PresentationalAttributes":    "Elements use obsolete presentational attributes",
+}
+
+// writeSARIF writes the findings and the inaccessible links of a page as SARIF results located at pageURL
+// the rule ids are the finding kinds and BrokenLink
+func writeSARIF(w io.Writer, pageURL string, fr *fetchResult, r *sortResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "go-web",
+			InformationURI: "https://github.com/jana-o/go-web",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]int{}
+	add := func(rule, message string) {
+		i, ok := rules[rule]
+		if !ok {
+			i = len(run.Tool.Driver.Rules)
+			rules[rule] = i
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule, ShortDescription: sarifMessage{Text: sarifDescriptions[rule]}})
+		}
+		level, ok := sarifLevels[rule]
+		if !ok {
+			level = "warning"
+		}
+		res := sarifResult{RuleID: rule, RuleIndex: i, Level: level, Message: sarifMessage{Text: message}}
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = pageURL
+		res.Locations = []sarifLocation{loc}
+		run.Results = append(run.Results, res)
+	}
+
+	for _, f := range fr.findings() {
+		msg := f.message
+		if len(f.examples) > 0 {
+			msg += ": " + strings.Join(f.examples, ", ")
+		}
+		add(f.kind, msg)
+	}
+	for _, l := range r.links {
+		if l.status != linkDown {
+			continue
+		}
+		if l.err != nil {
+			add("BrokenLink", fmt.Sprintf("link %s is inaccessible: %v", l.url, l.err))
+		} else {
+			add("BrokenLink", fmt.Sprintf("link %s returned status %d", l.url, l.code))
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
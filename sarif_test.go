@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	ts := fixtureServer(map[string]string{"/": "testdata/broken/index.html", "/ok": "testdata/broken/ok.html"})
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "sarif", ts.URL + "/"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var log sarifLog
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, stdout.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected one SARIF 2.1.0 run, got %+v", log)
+	}
+	run := log.Runs[0]
+	rules := map[string]bool{}
+	for _, r := range run.Tool.Driver.Rules {
+		rules[r.ID] = true
+		if r.ShortDescription.Text == "" || r.ShortDescription.Text == r.ID {
+			t.Errorf("expected a description of rule %s, got %q", r.ID, r.ShortDescription.Text)
+		}
+	}
+	if !rules["BrokenLink"] || !rules["MainLandmarkIssue"] {
+		t.Errorf("expected BrokenLink and MainLandmarkIssue rules, got %v", run.Tool.Driver.Rules)
+	}
+
+	broken := 0
+	for _, r := range run.Results {
+		if run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result %s refers to rule %d", r.RuleID, r.RuleIndex)
+		}
+		if len(r.Locations) != 1 || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != ts.URL+"/" {
+			t.Errorf("expected result %s to be located at the page, got %+v", r.RuleID, r.Locations)
+		}
+		if r.RuleID == "BrokenLink" {
+			broken++
+			if r.Level != "error" {
+				t.Errorf("expected broken links to be errors, got %s", r.Level)
+			}
+		}
+	}
+	if broken != 1 {
+		t.Errorf("expected 1 broken link result, got %d", broken)
+	}
+}
+
+func TestSARIFLevels(t *testing.T) {
+	fr := &fetchResult{crawlable: true, mainLandmarks: 1, missingDoctype: true, missingSRI: []string{"script https://cdn.example.com/lib.js"}}
+	var out bytes.Buffer
+	if err := writeSARIF(&out, "http://example.com/", fr, &sortResult{}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	levels := map[string]string{}
+	for _, r := range log.Runs[0].Results {
+		levels[r.RuleID] = r.Level
+	}
+	//security findings are errors, a missing doctype is only a note
+	if levels["MissingSRI"] != "error" || levels["MissingDoctype"] != "note" {
+		t.Errorf("expected MissingSRI to be an error and MissingDoctype a note, got %v", levels)
+	}
+}
+
+func TestSARIFDescriptions(t *testing.T) {
+	for kind := range scoreRules {
+		if sarifDescriptions[kind] == "" {
+			t.Errorf("expected a SARIF description of %s", kind)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// scoreCategories are the categories of --format scorecard in output order
+var scoreCategories = []string{"seo", "accessibility", "performance-signals", "security"}
+
+// scoreRule assigns a finding kind to a category, a finding deducts weight points from the 100 of its category
+type scoreRule struct {
+	category string
+	weight   int
+}
+
+// scoreRules are the documented default weights, --score-weights overrides them per kind
+// findings of kinds which are not listed don't affect the scores
+var scoreRules = map[string]scoreRule{
+	"NotCrawlable":                {"seo", 40},
+	"MissingDoctype":              {"seo", 10},
+	"ThinContent":                 {"seo", 15},
+	"MissingH1":                   {"seo", 15},
+	"MultipleH1":                  {"seo", 5},
+	"MetaDescriptionLength":       {"seo", 10},
+	"MissingMetaDescription":      {"seo", 10},
+	"CanonicalOgUrlMismatch":      {"seo", 10},
+	"CanonicalLoop":               {"seo", 15},
+	"CanonicalUnreachable":        {"seo", 15},
+	"RawURLAnchors":               {"seo", 5},
+	"MalformedLinks":              {"seo", 10},
+	"DeepURLs":                    {"seo", 5},
+	"StructuredDataWithoutJSONLD": {"seo", 5},
+
+	"MainLandmarkIssue":      {"accessibility", 10},
+	"DanglingLabel":          {"accessibility", 15},
+	"SharedLabelTarget":      {"accessibility", 5},
+	"InvalidAriaRoles":       {"accessibility", 10},
+	"DuplicateAccesskeys":    {"accessibility", 5},
+	"SvgAccessibilityIssues": {"accessibility", 10},
+	"NestedInteractive":      {"accessibility", 10},
+	"MissingAlt":             {"accessibility", 15},
+	"MissingSkipLink":        {"accessibility", 5},
+	"Autofocus":              {"accessibility", 5},
+	"SkippedHeadingLevels":   {"accessibility", 5},
+	"UnlabeledFields":        {"accessibility", 15},
+	"ContrastWarnings":       {"accessibility", 15},
+	"AutoplayMedia":          {"accessibility", 10},
+	"MobileUsability":        {"accessibility", 10},
+	"ViewportIssues":         {"accessibility", 10},
+	"PlaceholderLinks":       {"accessibility", 5},
+
+	"RenderBlockingCSS":     {"performance-signals", 10},
+	"PreloadMissingAs":      {"performance-signals", 5},
+	"ExcessiveFonts":        {"performance-signals", 10},
+	"CacheHeaders":          {"performance-signals", 10},
+	"ConditionalGetIgnored": {"performance-signals", 5},
+	"TrackingPixels":        {"performance-signals", 10},
+
+	"InsecureRedirectHop":     {"security", 40},
+	"PossibleCSRFMissing":     {"security", 15},
+	"MissingSRI":              {"security", 15},
+	"DeprecatedProtocolLinks": {"security", 5},
+}
+
+// loadScoreWeights reads a JSON object of finding kinds and their weights and returns scoreRules with them applied
+func loadScoreWeights(path string) (map[string]scoreRule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	weights := map[string]int{}
+	if err := json.Unmarshal(b, &weights); err != nil {
+		return nil, fmt.Errorf("Error parsing score weights %s: %v", path, err)
+	}
+	rules := map[string]scoreRule{}
+	for k, r := range scoreRules {
+		rules[k] = r
+	}
+	for k, w := range weights {
+		r, ok := rules[k]
+		if !ok {
+			return nil, fmt.Errorf("Error parsing score weights %s: %q is not a scored finding kind", path, k)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("Error parsing score weights %s: weight of %s is negative", path, k)
+		}
+		r.weight = w
+		rules[k] = r
+	}
+	return rules, nil
+}
+
+// jsonScorecard is the --format scorecard output of a page
+type jsonScorecard struct {
+	URL        string              `json:"url"`
+	Categories []jsonScoreCategory `json:"categories"`
+}
+
+type jsonScoreCategory struct {
+	Name string `json:"name"`
+	//Score is 100 minus the weights of Findings, at least 0
+	Score    int                `json:"score"`
+	Findings []jsonScoreFinding `json:"findings"`
+}
+
+type jsonScoreFinding struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Weight  int    `json:"weight"`
+}
+
+// newScorecard scores the findings of a page with rules
+// a list-type finding deducts its weight once regardless of the number of examples
+func newScorecard(fr *fetchResult, rules map[string]scoreRule) jsonScorecard {
+	card := jsonScorecard{URL: fr.url, Categories: []jsonScoreCategory{}}
+	byName := map[string]*jsonScoreCategory{}
+	for _, c := range scoreCategories {
+		card.Categories = append(card.Categories, jsonScoreCategory{Name: c, Score: 100, Findings: []jsonScoreFinding{}})
+	}
+	for i := range card.Categories {
+		byName[card.Categories[i].Name] = &card.Categories[i]
+	}
+	for _, f := range fr.findings() {
+		r, ok := rules[f.kind]
+		if !ok {
+			continue
+		}
+		c := byName[r.category]
+		c.Findings = append(c.Findings, jsonScoreFinding{Kind: f.kind, Message: f.message, Weight: r.weight})
+		c.Score -= r.weight
+		if c.Score < 0 {
+			c.Score = 0
+		}
+	}
+	return card
+}
+
+// writeScorecard writes the indented scorecard of a page
+func writeScorecard(w io.Writer, fr *fetchResult, rules map[string]scoreRule) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newScorecard(fr, rules))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScorecard(t *testing.T) {
+	//crawlable and a single main landmark keep NotCrawlable and MainLandmarkIssue out
+	fr := &fetchResult{
+		url:           "http://example.com/",
+		crawlable:     true,
+		mainLandmarks: 1,
+		missingH1:     true,
+		svgIssues:     []string{"svg#logo has no accessible name"},
+		lowContrast:   []string{"p.muted", "span.hint"},
+		insecureHops:  []string{"http://example.com/"},
+		//not a scored finding kind
+		conditionalComments: 2,
+	}
+	card := newScorecard(fr, scoreRules)
+	scores := map[string]int{}
+	kinds := map[string][]string{}
+	for _, c := range card.Categories {
+		scores[c.Name] = c.Score
+		for _, f := range c.Findings {
+			kinds[c.Name] = append(kinds[c.Name], f.Kind)
+		}
+	}
+	want := map[string]int{"seo": 85, "accessibility": 75, "performance-signals": 100, "security": 60}
+	if !reflect.DeepEqual(scores, want) {
+		t.Errorf("expected scores %v, got %v", want, scores)
+	}
+	wantKinds := map[string][]string{
+		"seo":           {"MissingH1"},
+		"accessibility": {"SvgAccessibilityIssues", "ContrastWarnings"},
+		"security":      {"InsecureRedirectHop"},
+	}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("expected contributing findings %v, got %v", wantKinds, kinds)
+	}
+
+	path := filepath.Join(t.TempDir(), "weights.json")
+	if err := ioutil.WriteFile(path, []byte(`{"MissingH1": 0, "InsecureRedirectHop": 150}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadScoreWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := writeScorecard(&out, fr, rules); err != nil {
+		t.Fatal(err)
+	}
+	var written jsonScorecard
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatalf("invalid scorecard: %v\n%s", err, out.String())
+	}
+	if seo, security := written.Categories[0], written.Categories[3]; seo.Score != 100 || security.Score != 0 {
+		t.Errorf("expected the overridden weights to score seo 100 and security 0, got %+v %+v", seo, security)
+	}
+	if scoreRules["MissingH1"].weight != 15 {
+		t.Error("expected the default weights to be unchanged")
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"Unknown": 5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadScoreWeights(path); err == nil {
+		t.Error("expected an error for an unknown finding kind")
+	}
+}
+
+func TestScorecardZoomDisabled(t *testing.T) {
+	//disabled zooming is no viewport issue as well, it is deducted once
+	fr := fetch(loadFixture(t, "viewport_no_zoom.html"))
+	for _, c := range newScorecard(fr, scoreRules).Categories {
+		if c.Name != "accessibility" {
+			continue
+		}
+		kinds := []string{}
+		for _, f := range c.Findings {
+			kinds = append(kinds, f.Kind)
+		}
+		if want := 100 - scoreRules["MobileUsability"].weight; c.Score != want || !reflect.DeepEqual(kinds, []string{"MobileUsability"}) {
+			t.Errorf("expected a single MobileUsability deduction to %d, got %d by %v", want, c.Score, kinds)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// defaultSearchTemplate searches Google, {query} is replaced by the query escaped for a query string
+const defaultSearchTemplate = "https://www.google.com/search?q={query}"
+
+// buildSearchURL returns template with {query} replaced by "site:<site> <term>"
+func buildSearchURL(template, site, term string) string {
+	return strings.Replace(template, "{query}", url.QueryEscape("site:"+site+" "+term), -1)
+}
+
+// searchSite fetches the search results for term on site and returns the unique result links to site
+func searchSite(ctx context.Context, template, site, term string) (string, []string, error) {
+	searchURL := buildSearchURL(template, site, term)
+	doc, res, err := parsePage(ctx, searchURL)
+	if err != nil {
+		return searchURL, nil, err
+	}
+	links := []string{}
+	for _, href := range getURLs(doc) {
+		u, err := res.Request.URL.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		//search engines often link results through a redirect like /url?q=<result>
+		if q := u.Query().Get("q"); u.Path == "/url" && q != "" {
+			if r, err := url.Parse(q); err == nil {
+				u = r
+			}
+		}
+		if onSite(u, site) && !contains(links, u.String()) {
+			links = append(links, u.String())
+		}
+	}
+	return searchURL, links, nil
+}
+
+// onSite returns true for http(s) urls on host site or its subdomains
+func onSite(u *url.URL, site string) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	site = strings.ToLower(site)
+	return host == site || strings.HasSuffix(host, "."+site)
+}
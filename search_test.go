@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchSite(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		http.ServeFile(w, r, "testdata/search_results.html")
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--search", "golang tips", "--site", "example.com", "--search-template", ts.URL + "/search?q={query}"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if query != "site:example.com golang tips" {
+		t.Errorf("expected the query 'site:example.com golang tips', got %q", query)
+	}
+	want := "Searched " + ts.URL + "/search?q=site%3Aexample.com+golang+tips\n" +
+		"found 2 result links to example.com:\n" +
+		"https://example.com/docs/install\n" +
+		"https://blog.example.com/golang-tips\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("expected output\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// upgradeInsecure contains the signals of a page upgrading insecure requests to https
+type upgradeInsecure struct {
+	//header and meta are set if Content-Security-Policy contains upgrade-insecure-requests
+	header bool
+	meta   bool
+	//varies is set if the server varies its response on the Upgrade-Insecure-Requests request header
+	varies bool
+	//redirected is set if a http url was redirected to https
+	redirected bool
+}
+
+// enabled returns true if the browser is told to upgrade the page's insecure requests
+func (u upgradeInsecure) enabled() bool {
+	return u.header || u.meta
+}
+
+func (u upgradeInsecure) String() string {
+	s := []string{}
+	if u.header {
+		s = append(s, "CSP header")
+	}
+	if u.meta {
+		s = append(s, "CSP meta")
+	}
+	if u.varies {
+		s = append(s, "Vary header")
+	}
+	if u.redirected {
+		s = append(s, "redirected to https")
+	}
+	if len(s) == 0 {
+		return "none"
+	}
+	return strings.Join(s, ", ")
+}
+
+// checkUpgradeInsecure looks for upgrade-insecure-requests in the Content-Security-Policy header and meta,
+// and whether the server reacted to the Upgrade-Insecure-Requests: 1 request header
+func checkUpgradeInsecure(doc *goquery.Document, res *http.Response) upgradeInsecure {
+	u := upgradeInsecure{}
+	for _, v := range res.Header.Values("Content-Security-Policy") {
+		u.header = u.header || cspHasDirective(v, "upgrade-insecure-requests")
+	}
+	doc.Find("meta[http-equiv]").Each(func(i int, s *goquery.Selection) {
+		if strings.EqualFold(strings.TrimSpace(s.AttrOr("http-equiv", "")), "Content-Security-Policy") {
+			u.meta = u.meta || cspHasDirective(s.AttrOr("content", ""), "upgrade-insecure-requests")
+		}
+	})
+	for _, v := range res.Header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			u.varies = u.varies || strings.EqualFold(strings.TrimSpace(h), "Upgrade-Insecure-Requests")
+		}
+	}
+
+	//the request of a redirect target links back to the redirect response
+	first := res.Request
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	u.redirected = first.URL.Scheme == "http" && res.Request.URL.Scheme == "https"
+	return u
+}
+
+// cspHasDirective returns true if the policy contains the directive
+func cspHasDirective(policy, directive string) bool {
+	for _, d := range strings.Split(policy, ";") {
+		fields := strings.Fields(d)
+		if len(fields) > 0 && strings.EqualFold(fields[0], directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// insecureRedirectHops returns the http urls of a redirect chain ending at final which follow an https url
+// such a hop sends the request in clear text although the page was requested securely
+func insecureRedirectHops(hops []redirectHop, final string) []string {
+	urls := []string{}
+	for _, h := range hops {
+		urls = append(urls, h.url)
+	}
+	urls = append(urls, final)
+
+	insecure := []string{}
+	secure := false
+	for _, u := range urls {
+		switch {
+		case strings.HasPrefix(strings.ToLower(u), "https://"):
+			secure = true
+		case secure && strings.HasPrefix(strings.ToLower(u), "http://"):
+			insecure = append(insecure, u)
+		}
+	}
+	return insecure
+}
+
+// csrfTokenNames match the names of hidden csrf token fields of common frameworks, ignoring case
+var csrfTokenNames = []string{"csrf", "xsrf", "_token", "authenticity_token", "__requestverificationtoken"}
+
+// getFormsWithoutCSRFToken describes the POST forms without a hidden input named like a csrf token
+// this is a heuristic: forms may be protected by other means, e.g. SameSite cookies or a token sent by script
+func getFormsWithoutCSRFToken(doc *goquery.Document) []string {
+	forms := []string{}
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		if !strings.EqualFold(strings.TrimSpace(s.AttrOr("method", "")), "post") {
+			return
+		}
+		protected := false
+		s.Find("input[type][name]").Each(func(i int, in *goquery.Selection) {
+			if !strings.EqualFold(strings.TrimSpace(in.AttrOr("type", "")), "hidden") {
+				return
+			}
+			name := strings.ToLower(in.AttrOr("name", ""))
+			for _, n := range csrfTokenNames {
+				protected = protected || strings.Contains(name, n)
+			}
+		})
+		if protected {
+			return
+		}
+		desc := "form"
+		if id := strings.TrimSpace(s.AttrOr("id", "")); id != "" {
+			desc += "#" + id
+		}
+		if action := strings.TrimSpace(s.AttrOr("action", "")); action != "" {
+			desc += " action=" + action
+		}
+		forms = append(forms, desc)
+	})
+	return forms
+}
+
+// getMissingSRI describes the cross-origin scripts and stylesheets without an integrity attribute
+// with integrity but without crossorigin the browser can not verify the resource and blocks it, so that is reported as well
+// resources of the page's own origin are exempt
+func getMissingSRI(doc *goquery.Document) []string {
+	missing := []string{}
+	base := documentBase(doc)
+	if base == nil || doc.Url == nil {
+		return missing
+	}
+	check := func(s *goquery.Selection, name, attr string) {
+		href := strings.TrimSpace(s.AttrOr(attr, ""))
+		u, err := base.Parse(href)
+		if href == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || sameOrigin(u, doc.Url) {
+			return
+		}
+		_, crossorigin := s.Attr("crossorigin")
+		switch {
+		case strings.TrimSpace(s.AttrOr("integrity", "")) == "":
+			missing = append(missing, name+" "+u.String()+" has no integrity")
+		case !crossorigin:
+			missing = append(missing, name+" "+u.String()+" has integrity but no crossorigin")
+		}
+	}
+	doc.Find("script[src]").Each(func(i int, s *goquery.Selection) {
+		check(s, "script", "src")
+	})
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		if contains(strings.Fields(strings.ToLower(s.AttrOr("rel", ""))), "stylesheet") {
+			check(s, "stylesheet", "href")
+		}
+	})
+	return missing
+}
+
+// sameOrigin compares scheme, host and port of a and b
+func sameOrigin(a, b *url.URL) bool {
+	port := func(u *url.URL) string {
+		if p := u.Port(); p != "" {
+			return p
+		}
+		if strings.EqualFold(u.Scheme, "https") {
+			return "443"
+		}
+		return "80"
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && port(a) == port(b)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeInsecureRequests(t *testing.T) {
+	var sent string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/header", func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("Upgrade-Insecure-Requests")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; upgrade-insecure-requests")
+		w.Header().Set("Vary", "Accept-Encoding, Upgrade-Insecure-Requests")
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/upgrade_insecure.html")
+	})
+	mux.HandleFunc("/none", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; block-all-mixed-content")
+		http.ServeFile(w, r, "testdata/plain.html")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		path string
+		want upgradeInsecure
+	}{
+		{"/header", upgradeInsecure{header: true, varies: true}},
+		{"/meta", upgradeInsecure{meta: true}},
+		{"/none", upgradeInsecure{}},
+	}
+	for _, tt := range tests {
+		fr, err := analyzePage(context.Background(), ts.URL+tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fr.upgradeInsecureRequests != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.path, tt.want, fr.upgradeInsecureRequests)
+		}
+	}
+	if sent != "1" {
+		t.Errorf("expected the Upgrade-Insecure-Requests: 1 request header, got %q", sent)
+	}
+}
+
+func TestInsecureRedirectHop(t *testing.T) {
+	//https /start -> http /hop -> https /final
+	var secureURL string
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, secureURL+"/final", http.StatusFound)
+	}))
+	defer plain.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/hop", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html><html><head><title>Final</title></head><body><main><h1>Final</h1></main></body></html>`))
+	})
+	secure := httptest.NewTLSServer(mux)
+	defer secure.Close()
+	secureURL = secure.URL
+
+	defer func(orig http.RoundTripper) { client.Transport = orig }(client.Transport)
+	client.Transport = secure.Client().Transport
+
+	fr, err := analyzePage(context.Background(), secure.URL+"/start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fr.insecureHops) != 1 || fr.insecureHops[0] != plain.URL+"/hop" {
+		t.Errorf("expected the http hop %s to be flagged, got %v", plain.URL+"/hop", fr.insecureHops)
+	}
+	if !hasFinding(fr, "InsecureRedirectHop") {
+		t.Errorf("expected an InsecureRedirectHop finding, got %v", fr.findings())
+	}
+
+	//upgrading http to https is no insecure hop
+	hops := insecureRedirectHops([]redirectHop{{url: "http://example.com/", status: 301}}, "https://example.com/")
+	if len(hops) != 0 {
+		t.Errorf("expected an upgrade to https not to be flagged, got %v", hops)
+	}
+}
+
+func TestPossibleCSRFMissing(t *testing.T) {
+	ts := fixtureServer(map[string]string{"/": "testdata/csrf.html"})
+	defer ts.Close()
+
+	fr, err := analyzePage(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"form action=/comments"}
+	if !reflect.DeepEqual(fr.formsWithoutCSRFToken, want) {
+		t.Errorf("expected only the comment form to be unprotected, got %v", fr.formsWithoutCSRFToken)
+	}
+	if !hasFinding(fr, "PossibleCSRFMissing") {
+		t.Errorf("expected a PossibleCSRFMissing finding, got %v", fr.findings())
+	}
+}
+
+func TestMissingSRI(t *testing.T) {
+	doc := loadFixture(t, "sri.html")
+	doc.Url, _ = url.Parse("https://www.example.com/page")
+	want := []string{
+		"script https://cdn.example.net/widget.js has no integrity",
+		"stylesheet https://fonts.example.org/theme.css has integrity but no crossorigin",
+	}
+	got := getMissingSRI(doc)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if fr := (&fetchResult{missingSRI: got}); !hasFinding(fr, "MissingSRI") {
+		t.Errorf("expected a MissingSRI finding, got %v", fr.findings())
+	}
+}
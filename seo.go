@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// structuredData contains the structured data formats a page uses
+type structuredData struct {
+	jsonLD    bool
+	microdata bool
+	rdfa      bool
+}
+
+// getStructuredData finds JSON-LD scripts, microdata (itemscope/itemtype) and RDFa (vocab/typeof/property)
+// property on meta is ignored because Open Graph tags use it without being RDFa markup
+func getStructuredData(doc *goquery.Document) structuredData {
+	return structuredData{
+		jsonLD:    doc.Find(`script[type="application/ld+json"]`).Length() > 0,
+		microdata: doc.Find("[itemscope], [itemtype]").Length() > 0,
+		rdfa:      doc.Find("[vocab], [typeof]").Length() > 0 || doc.Find("[property]").Not("meta").Length() > 0,
+	}
+}
+
+// formats returns the names of the formats used
+func (sd structuredData) formats() []string {
+	f := []string{}
+	if sd.jsonLD {
+		f = append(f, "JSON-LD")
+	}
+	if sd.microdata {
+		f = append(f, "microdata")
+	}
+	if sd.rdfa {
+		f = append(f, "RDFa")
+	}
+	return f
+}
+
+// withoutJSONLD returns true if microdata or RDFa is used but not JSON-LD, which Google prefers
+func (sd structuredData) withoutJSONLD() bool {
+	return (sd.microdata || sd.rdfa) && !sd.jsonLD
+}
+
+func (sd structuredData) String() string {
+	f := sd.formats()
+	if len(f) == 0 {
+		return "none"
+	}
+	return strings.Join(f, ", ")
+}
+
+// getCanonical returns the href of <link rel="canonical">, if any
+func getCanonical(doc *goquery.Document) string {
+	canonical := ""
+	doc.Find("link[rel][href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if contains(strings.Fields(strings.ToLower(s.AttrOr("rel", ""))), "canonical") {
+			canonical = strings.TrimSpace(s.AttrOr("href", ""))
+		}
+		return canonical == ""
+	})
+	return canonical
+}
+
+// sameURL compares normalized urls, relative urls are resolved against the documentBase if it is known
+func sameURL(doc *goquery.Document, a, b string) bool {
+	base := documentBase(doc)
+	if base == nil {
+		base = &url.URL{}
+	}
+	ua, errA := base.Parse(a)
+	ub, errB := base.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return normalizeURL(ua) == normalizeURL(ub)
+}
+
+// getRawURLAnchors returns the hrefs of anchors whose visible text is the url itself instead of a description
+func getRawURLAnchors(doc *goquery.Document) []string {
+	raw := []string{}
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		text := strings.TrimSpace(s.Text())
+		if href == "" || text == "" {
+			return
+		}
+		if bareURL(text) == bareURL(href) {
+			raw = append(raw, href)
+		}
+	})
+	return raw
+}
+
+// bareURL strips scheme, www., trailing slash and case so that "https://www.example.com/" matches "example.com"
+func bareURL(s string) string {
+	s = strings.ToLower(s)
+	for _, prefix := range []string{"https://", "http://", "mailto:", "//", "www."} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return strings.TrimSuffix(s, "/")
+}
+
+// maxCanonicalHops limits how many canonical targets resolveCanonical follows
+const maxCanonicalHops = 5
+
+// resolveCanonical analyzes the canonical target of fr if it differs from fr's url, and the target's canonical in turn
+// it stops at a self-referencing canonical or a page already seen, which is reported as loop ("a -> b -> a")
+// a target which can not be analyzed ends the chain, it is reported as unreachable with its error
+func resolveCanonical(ctx context.Context, fr *fetchResult) (targets []*fetchResult, loop, unreachable string) {
+	seen := []string{}
+	current := fr
+	for i := 0; i < maxCanonicalHops; i++ {
+		u, err := url.Parse(current.url)
+		if err != nil || current.canonical == "" {
+			return targets, "", ""
+		}
+		seen = append(seen, normalizeURL(u))
+		//a relative canonical resolves against the <base href> like the links
+		base, err := url.Parse(current.linkBase())
+		if err != nil {
+			return targets, "", ""
+		}
+		next, err := base.Parse(current.canonical)
+		if err != nil {
+			return targets, "", ""
+		}
+		n := normalizeURL(next)
+		if n == seen[len(seen)-1] {
+			return targets, "", ""
+		}
+		if contains(seen, n) {
+			return targets, strings.Join(append(seen, n), " -> "), ""
+		}
+
+		target, err := analyzePage(ctx, n)
+		if err != nil {
+			return targets, "", fmt.Sprintf("%s: %v", n, err)
+		}
+		targets = append(targets, target)
+		current = target
+	}
+	return targets, "", ""
+}
+
+// getPagination returns the hrefs of <link rel="next"> and <link rel="prev">, rel="previous" is accepted too
+func getPagination(doc *goquery.Document) (next, prev string) {
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch {
+			case rel == "next" && next == "":
+				next = href
+			case (rel == "prev" || rel == "previous") && prev == "":
+				prev = href
+			}
+		}
+	})
+	return next, prev
+}
+
+// meta description lengths in characters, search engines cut longer descriptions and may replace shorter ones
+const (
+	minMetaDescription = 50
+	maxMetaDescription = 160
+)
+
+// getMetaDescription returns the whitespace normalized content of <meta name="description"> and whether there is one
+func getMetaDescription(doc *goquery.Document) (string, bool) {
+	found := false
+	description := ""
+	doc.Find("meta[name]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if !strings.EqualFold(strings.TrimSpace(s.AttrOr("name", "")), "description") {
+			return true
+		}
+		found = true
+		description = strings.Join(strings.Fields(s.AttrOr("content", "")), " ")
+		return false
+	})
+	return description, found
+}
+
+// metaDescriptionIssue returns why the length of the meta description is a problem, or "" if it is not
+func metaDescriptionIssue(description string, found bool) string {
+	n := utf8.RuneCountInString(description)
+	switch {
+	case !found:
+		return "page has no meta description"
+	case n == 0:
+		return "meta description is empty"
+	case n < minMetaDescription:
+		return fmt.Sprintf("meta description has %d characters, less than %d", n, minMetaDescription)
+	case n > maxMetaDescription:
+		return fmt.Sprintf("meta description has %d characters, more than %d", n, maxMetaDescription)
+	}
+	return ""
+}
+
+// placeholderHref returns true for empty, "#" and javascript: hrefs, the anchors are driven by scripts and do not navigate
+// an empty href only reloads the page
+func placeholderHref(href string) bool {
+	href = strings.TrimSpace(href)
+	return href == "" || href == "#" || strings.HasPrefix(strings.ToLower(href), "javascript:")
+}
+
+// countPlaceholderLinks counts the anchors with a placeholderHref, they are probably buttons
+func countPlaceholderLinks(doc *goquery.Document) int {
+	n := 0
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		if placeholderHref(s.AttrOr("href", "")) {
+			n++
+		}
+	})
+	return n
+}
+
+// pathDepth counts the non-empty segments of the path of u, / is 0 and /a/b/ is 2
+func pathDepth(u *url.URL) int {
+	n := 0
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// templateMarkers are remains of unrendered templates in a path
+var templateMarkers = []string{"{{", "}}", "${", "<%"}
+
+// malformedLinkReason returns why the resolved path of link looks like a templating bug, "" if it looks fine
+// the path is checked after resolving against base, so a protocol relative //host/path has no double slash
+func malformedLinkReason(base *url.URL, link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "unparsable url"
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	if strings.Contains(u.Path, "//") {
+		return "double slash in path"
+	}
+	for _, m := range templateMarkers {
+		if strings.Contains(u.Path, m) {
+			return "template placeholder " + m + " in path"
+		}
+	}
+	//segments of unset script variables
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg == "undefined" || seg == "null" || seg == "NaN" {
+			return seg + " in path"
+		}
+	}
+	return ""
+}
+
+// getMalformedLinks describes the links whose path looks malformed, see malformedLinkReason
+func getMalformedLinks(base *url.URL, links []string) []string {
+	malformed := []string{}
+	for _, l := range links {
+		if reason := malformedLinkReason(base, l); reason != "" {
+			malformed = append(malformed, l+" ("+reason+")")
+		}
+	}
+	return malformed
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStructuredData(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		want          structuredData
+		withoutJSONLD bool
+	}{
+		{"jsonld.html", structuredData{jsonLD: true}, false},
+		{"microdata.html", structuredData{microdata: true}, true},
+		{"rdfa.html", structuredData{rdfa: true}, true},
+		{"plain.html", structuredData{}, false},
+	}
+	for _, tt := range tests {
+		got := getStructuredData(loadFixture(t, tt.fixture))
+		if got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.fixture, tt.want, got)
+		}
+		if got.withoutJSONLD() != tt.withoutJSONLD {
+			t.Errorf("%s: expected withoutJSONLD %t", tt.fixture, tt.withoutJSONLD)
+		}
+	}
+}
+
+func TestCanonicalOgURLMismatch(t *testing.T) {
+	fr := fetch(loadFixture(t, "canonical_og.html"))
+	if !fr.canonicalOgURLMismatch {
+		t.Errorf("expected canonical %s and og:url %s to mismatch", fr.canonical, fr.ogURL)
+	}
+	if !hasFinding(fr, "CanonicalOgUrlMismatch") {
+		t.Error("expected a CanonicalOgUrlMismatch finding")
+	}
+
+	doc := loadFixture(t, "canonical_og_match.html")
+	doc.Url, _ = url.Parse("https://example.com/products/shoes?ref=home")
+	if fr := fetch(doc); fr.canonicalOgURLMismatch {
+		t.Errorf("expected canonical %s and og:url %s to match after normalizing", fr.canonical, fr.ogURL)
+	}
+
+	//without og:url there is nothing to compare
+	if fr := fetch(loadFixture(t, "plain.html")); fr.canonicalOgURLMismatch {
+		t.Error("expected no mismatch without canonical and og:url")
+	}
+}
+
+func TestRawURLAnchors(t *testing.T) {
+	fr := fetch(loadFixture(t, "raw_url_anchors.html"))
+	want := []string{"https://www.example.com/pricing/"}
+	if !reflect.DeepEqual(fr.rawURLAnchors, want) {
+		t.Fatalf("expected %v, got %v", want, fr.rawURLAnchors)
+	}
+	if !hasFinding(fr, "RawURLAnchors") {
+		t.Error("expected a RawURLAnchors finding")
+	}
+}
+
+func TestResolveCanonical(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/canonical-source": "testdata/canonical_source.html",
+		"/canonical-target": "testdata/canonical_target.html",
+	})
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"--resolve-canonical", ts.URL + "/canonical-source"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"Website title: Duplicate listing",
+		"Canonical target " + ts.URL + "/canonical-target:\nWebsite title: Main listing",
+		//the target's canonical points back to the source
+		"Warning: canonical loop " + ts.URL + "/canonical-source -> " + ts.URL + "/canonical-target -> " + ts.URL + "/canonical-source",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "Website title:"); n != 2 {
+		t.Errorf("expected 2 analyzed pages, got %d", n)
+	}
+
+	//json and the findings contain the targets and the loop too
+	stdout.Reset()
+	if code := run([]string{"--resolve-canonical", "--format", "json", ts.URL + "/canonical-source"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.CanonicalTargets) != 1 || rep.CanonicalTargets[0].URL != ts.URL+"/canonical-target" || rep.CanonicalTargets[0].Title != "Main listing" {
+		t.Errorf("expected the canonical target in json, got %+v", rep.CanonicalTargets)
+	}
+	if want := ts.URL + "/canonical-source -> " + ts.URL + "/canonical-target -> " + ts.URL + "/canonical-source"; rep.CanonicalLoop != want {
+		t.Errorf("expected canonical loop %q, got %q", want, rep.CanonicalLoop)
+	}
+	kinds := []string{}
+	for _, f := range rep.Findings {
+		kinds = append(kinds, f.Kind)
+	}
+	if !contains(kinds, "CanonicalTargets") || !contains(kinds, "CanonicalLoop") {
+		t.Errorf("expected CanonicalTargets and CanonicalLoop findings, got %v", kinds)
+	}
+}
+
+func TestResolveCanonicalBase(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/old/listing":   "testdata/canonical_base.html",
+		"/listings/main": "testdata/canonical_target.html",
+	})
+	defer ts.Close()
+
+	fr, err := analyzePage(context.Background(), ts.URL+"/old/listing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, _, _ := resolveCanonical(context.Background(), fr)
+	if len(targets) != 1 || targets[0].url != ts.URL+"/listings/main" {
+		t.Errorf("expected the relative canonical to resolve against the base href, got %v", targets)
+	}
+}
+
+func TestResolveCanonicalUnreachable(t *testing.T) {
+	//the canonical target /listings/main answers 404
+	ts := fixtureServer(map[string]string{"/old/listing": "testdata/canonical_base.html"})
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--resolve-canonical", "--format", "json", ts.URL + "/old/listing"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var rep jsonReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if want := ts.URL + "/listings/main: Error response status code was 404"; rep.CanonicalUnreachable != want {
+		t.Errorf("expected canonical target %q to be unreachable, got %q", want, rep.CanonicalUnreachable)
+	}
+	found := false
+	for _, f := range rep.Findings {
+		found = found || f.Kind == "CanonicalUnreachable"
+	}
+	if !found || len(rep.CanonicalTargets) != 0 {
+		t.Errorf("expected a CanonicalUnreachable finding without targets, got %+v", rep.Findings)
+	}
+}
+
+func TestThinContent(t *testing.T) {
+	defer func(orig thresholds) { limits = orig }(limits)
+	limits.minWords = 20
+
+	thin := fetch(loadFixture(t, "thin.html"))
+	if thin.wordCount != 5 || !thin.thinContent || !hasFinding(thin, "ThinContent") {
+		t.Errorf("expected a thin page with 5 words, got %d words, thin %t", thin.wordCount, thin.thinContent)
+	}
+	article := fetch(loadFixture(t, "article.html"))
+	if article.thinContent || hasFinding(article, "ThinContent") {
+		t.Errorf("expected the article with %d words not to be thin", article.wordCount)
+	}
+
+	limits.minWords = 0
+	if fetch(loadFixture(t, "thin.html")).thinContent {
+		t.Error("expected no thin content check without --min-words")
+	}
+}
+
+func TestMetaDescriptionLength(t *testing.T) {
+	tests := []struct {
+		fixture string
+		issue   string
+		kind    string
+	}{
+		{"plain.html", "page has no meta description", "MissingMetaDescription"},
+		{"description_empty.html", "meta description is empty", "MetaDescriptionLength"},
+		{"description_short.html", "meta description has 12 characters, less than 50", "MetaDescriptionLength"},
+		{"description_ideal.html", "", ""},
+		{"description_long.html", "meta description has 192 characters, more than 160", "MetaDescriptionLength"},
+	}
+	for _, tt := range tests {
+		fr := fetch(loadFixture(t, tt.fixture))
+		if fr.metaDescriptionIssue != tt.issue {
+			t.Errorf("%s: expected %q, got %q", tt.fixture, tt.issue, fr.metaDescriptionIssue)
+		}
+		for _, kind := range []string{"MissingMetaDescription", "MetaDescriptionLength"} {
+			if hasFinding(fr, kind) != (kind == tt.kind) {
+				t.Errorf("%s: expected %s %t", tt.fixture, kind, kind == tt.kind)
+			}
+		}
+	}
+}
+
+func TestMetaDescriptionJSON(t *testing.T) {
+	ts := fixtureServer(map[string]string{
+		"/short": "testdata/description_short.html",
+		"/none":  "testdata/plain.html",
+	})
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		has    bool
+		length int
+	}{
+		{"/short", true, 12},
+		{"/none", false, 0},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"--format", "json", ts.URL + tt.path}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+		}
+		var rep jsonReport
+		if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+			t.Fatal(err)
+		}
+		if rep.HasMetaDescription != tt.has || rep.MetaDescriptionLength != tt.length || len([]rune(rep.MetaDescription)) != tt.length {
+			t.Errorf("%s: expected description %t of %d characters, got %+v", tt.path, tt.has, tt.length, rep)
+		}
+	}
+}
+
+func TestPlaceholderLinks(t *testing.T) {
+	fr := fetch(loadFixture(t, "placeholder_links.html"))
+	//empty hrefs are placeholders as well
+	if fr.placeholderLinks != 5 {
+		t.Errorf("expected 5 placeholder links, got %d", fr.placeholderLinks)
+	}
+	if !reflect.DeepEqual(fr.urls, []string{"#reviews", "/products"}) {
+		t.Errorf("expected placeholder links not to be collected as urls, got %v", fr.urls)
+	}
+	if !hasFinding(fr, "PlaceholderLinks") {
+		t.Errorf("expected a PlaceholderLinks finding, got %v", fr.findings())
+	}
+}
+
+func TestDeepURLs(t *testing.T) {
+	defer func(orig thresholds) { limits = orig }(limits)
+	limits.maxPathDepth = 5
+	ts := fixtureServer(map[string]string{
+		"/":              "testdata/deep/index.html",
+		"/shoes/running": "testdata/deep/page.html",
+		"/shoes/running/trail/women/sale/2020/model-x": "testdata/deep/page.html",
+	})
+	defer ts.Close()
+
+	pages, err := (&crawler{maxPages: 10, maxDepth: 3}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := []string{}
+	for _, p := range pages {
+		if p.result != nil && hasFinding(p.result, "DeepURLs") {
+			flagged = append(flagged, p.url)
+		}
+	}
+	if want := []string{ts.URL + "/shoes/running/trail/women/sale/2020/model-x"}; !reflect.DeepEqual(flagged, want) {
+		t.Errorf("expected only %v to be flagged, got %v", want, flagged)
+	}
+}
+
+func TestMalformedLinks(t *testing.T) {
+	doc := loadFixture(t, "malformed_links.html")
+	doc.Url, _ = url.Parse("https://example.com/guide/start")
+	fr := fetch(doc)
+	want := []string{
+		"/a//b (double slash in path)",
+		"https://example.com/products/{{product.id}} (template placeholder {{ in path)",
+		"/users/undefined/profile (undefined in path)",
+	}
+	if !reflect.DeepEqual(fr.malformedLinks, want) {
+		t.Errorf("expected %v, got %v", want, fr.malformedLinks)
+	}
+	if !hasFinding(fr, "MalformedLinks") {
+		t.Errorf("expected a MalformedLinks finding, got %v", fr.findings())
+	}
+}
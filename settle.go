@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// settleResult compares the markup of a page with a second fetch after a delay, see --settle
+type settleResult struct {
+	checked bool
+	delay   time.Duration
+	//added and removed count the lines of the second markup which differ from the first
+	added   int
+	removed int
+	err     error
+}
+
+// changed returns true if the second fetch returned different markup
+func (s settleResult) changed() bool {
+	return s.added > 0 || s.removed > 0
+}
+
+func (s settleResult) String() string {
+	switch {
+	case s.err != nil:
+		return fmt.Sprintf("second fetch after %s failed: %v", s.delay, s.err)
+	case s.changed():
+		return fmt.Sprintf("markup changed after %s, %d lines added and %d removed", s.delay, s.added, s.removed)
+	}
+	return fmt.Sprintf("markup unchanged after %s", s.delay)
+}
+
+// checkSettle waits for delay, fetches the page of first again and diffs the rendered markup line by line
+// this detects servers streaming or rotating content, scripts are not executed
+func checkSettle(ctx context.Context, first *goquery.Document, delay time.Duration) settleResult {
+	s := settleResult{checked: true, delay: delay}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		s.err = ctx.Err()
+		return s
+	}
+	second, _, err := parsePage(ctx, first.Url.String())
+	if err != nil {
+		s.err = err
+		return s
+	}
+	a, err := goquery.OuterHtml(first.Selection)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	b, err := goquery.OuterHtml(second.Selection)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	s.added, s.removed = lineDiff(strings.Split(a, "\n"), strings.Split(b, "\n"))
+	return s
+}
+
+// lineDiff counts the lines of b which are not in a and the lines of a which are not in b, respecting duplicates
+func lineDiff(a, b []string) (added int, removed int) {
+	count := map[string]int{}
+	for _, l := range a {
+		count[strings.TrimSpace(l)]++
+	}
+	for _, l := range b {
+		l = strings.TrimSpace(l)
+		if count[l] > 0 {
+			count[l]--
+		} else {
+			added++
+		}
+	}
+	for _, n := range count {
+		removed += n
+	}
+	return added, removed
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckSettle(t *testing.T) {
+	var requests int32
+	handlers := http.NewServeMux()
+	//the second request gets an additional streamed paragraph
+	handlers.HandleFunc("/dynamic", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Dynamic</title></head><body>\n<p>first</p>\n")
+		if atomic.AddInt32(&requests, 1) > 1 {
+			fmt.Fprint(w, "<p>streamed</p>\n")
+		}
+		fmt.Fprint(w, "</body></html>")
+	})
+	handlers.HandleFunc("/static", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Static</title></head><body>\n<p>first</p>\n</body></html>")
+	})
+	ts := httptest.NewServer(handlers)
+	defer ts.Close()
+
+	tests := []struct {
+		path    string
+		added   int
+		changed bool
+	}{
+		{"/dynamic", 1, true},
+		{"/static", 0, false},
+	}
+	for _, tt := range tests {
+		doc, res, err := parsePage(context.Background(), ts.URL+tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr := analyzeDocument(context.Background(), doc, res)
+		fr.settle = checkSettle(context.Background(), doc, 10*time.Millisecond)
+		s := fr.settle
+		if !s.checked || s.err != nil || s.added != tt.added || s.removed != 0 {
+			t.Errorf("%s: expected %d added lines, got %+v", tt.path, tt.added, s)
+		}
+		if s.changed() != tt.changed || hasFinding(fr, "DynamicContentDetected") != tt.changed {
+			t.Errorf("%s: expected DynamicContentDetected %t, got %v", tt.path, tt.changed, fr.findings())
+		}
+	}
+}
+
+func TestCheckSettleCanceled(t *testing.T) {
+	doc := loadFixture(t, "plain.html")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := checkSettle(ctx, doc, time.Hour)
+	if s.err != context.Canceled || s.changed() {
+		t.Errorf("expected the canceled wait to fail without a change, got %+v", s)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	added, removed := lineDiff([]string{"a", " b", "b", "c"}, []string{"a", "b", "d", "e"})
+	if added != 2 || removed != 2 {
+		t.Errorf("expected 2 added and 2 removed lines, got %d and %d", added, removed)
+	}
+}
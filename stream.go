@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// streamedChecks are the analyzers whose fields streamPage fills, the title of meta is extracted too
+var streamedChecks = []string{"headings", "links"}
+
+// streamPage extracts the title, headings and links of a page with the tokenizer, without building a node tree
+// the results match fetch for the same document, it is used with --streaming for very large pages
+func streamPage(r io.Reader) (*fetchResult, error) {
+	fr := &fetchResult{headings: map[string]int{"h1": 0, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0}, h1Texts: []string{}}
+	links := &urlSet{urls: []string{}}
+	var title, h1 strings.Builder
+	inTitle, inH1 := false, false
+	//like the parser, a heading start or end tag closes an open h1
+	closeH1 := func() {
+		if inH1 {
+			fr.h1Texts = append(fr.h1Texts, strings.Join(strings.Fields(h1.String()), " "))
+			inH1 = false
+		}
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return nil, z.Err()
+			}
+			closeH1()
+			fr.title = title.String()
+			fr.urls = links.urls
+			fr.missingH1 = len(fr.h1Texts) == 0
+			fr.multipleH1 = len(fr.h1Texts) > 1
+			return fr, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			switch tag {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				fr.headings[tag]++
+				closeH1()
+				if tag == "h1" && tt == html.StartTagToken {
+					inH1 = true
+					h1.Reset()
+				}
+			case "a":
+				//the parser keeps the first of duplicate attributes
+				href := ""
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					if string(key) == "href" {
+						href = string(val)
+						break
+					}
+				}
+				links.add(href)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				closeH1()
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+			if inH1 {
+				h1.Write(z.Text())
+			}
+		}
+	}
+}
+
+// streamAnalyzePage fetches url and streams its body through streamPage, the other analyzers are skipped
+func streamAnalyzePage(ctx context.Context, url string) (*fetchResult, error) {
+	res, err := getWithContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error response status code was %d", res.StatusCode)
+	}
+
+	fr, err := streamPage(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading HTTP response body %v", err)
+	}
+	fr.url = res.Request.URL.String()
+	fr.redirects = redirectChain(res)
+	for _, name := range checkNames() {
+		if !contains(streamedChecks, name) {
+			fr.skip(name)
+		}
+	}
+	return fr, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// largePage returns a page of several megabytes with headings, duplicate, placeholder and escaped links
+func largePage(sections int) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<title>Catalog &amp; archive</title>\n</head>\n<body>\n")
+	b.WriteString("<h1>The <em>complete</em>\n catalog</h1>\n<a href=\"#\">Menu</a>\n")
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&b, "<h2>Section %d</h2>\n<p>%s</p>\n", i, strings.Repeat("Lorem ipsum dolor sit amet. ", 40))
+		fmt.Fprintf(&b, "<a href=\"/items/%d?sort=asc&amp;page=2\">Item %d</a>\n", i, i)
+		fmt.Fprintf(&b, "<a href=\"/items/%d\">Item %d</a>\n<a href=\"/items/%d\">Again</a>\n", i%10, i, i%10)
+		if i%100 == 0 {
+			fmt.Fprintf(&b, "<h3>Group %d</h3>\n<a>No href</a>\n<a href=\"javascript:void(0)\">Expand</a>\n", i/100)
+		}
+	}
+	b.WriteString("<h1>Second title</h1>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func TestStreamPageMatchesGoquery(t *testing.T) {
+	page := largePage(3000)
+	if len(page) < 1<<20 {
+		t.Fatalf("expected a large page, got %d bytes", len(page))
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fetch(doc)
+	got, err := streamPage(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.title != want.title {
+		t.Errorf("expected title %q, got %q", want.title, got.title)
+	}
+	if !reflect.DeepEqual(got.headings, want.headings) {
+		t.Errorf("expected headings %v, got %v", want.headings, got.headings)
+	}
+	if !reflect.DeepEqual(got.h1Texts, want.h1Texts) || got.multipleH1 != want.multipleH1 || got.missingH1 != want.missingH1 {
+		t.Errorf("expected h1 %v, got %v", want.h1Texts, got.h1Texts)
+	}
+	if !reflect.DeepEqual(got.urls, want.urls) {
+		t.Errorf("expected %d urls, got %d", len(want.urls), len(got.urls))
+	}
+}
+
+func TestStreamingOption(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(largePage(10)))
+		}
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--streaming", ts.URL}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	//anchors without href, like empty hrefs, are no links
+	for _, want := range []string{"found 20 internal links and 0\n", "Website title: Catalog & archive \n", "10 - h2\n", "Warning: page has 2 h1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Caching:") {
+		t.Errorf("expected the other analyzers to be skipped, got\n%s", out)
+	}
+
+	if code := run([]string{"--streaming", "--toc", ts.URL}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d for --streaming with --toc, got %d", exitUsage, code)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestThrottle adapts the request rate per host to 429 and 503 responses if not nil, see doRequest
+var requestThrottle *throttle
+
+const (
+	//minThrottleInterval is the interval between requests to a host after its first throttling response
+	minThrottleInterval = 100 * time.Millisecond
+	maxThrottleInterval = 30 * time.Second
+)
+
+// throttle spaces the requests to hosts which answered 429 Too Many Requests or 503 Service Unavailable
+// every throttling response doubles the interval between requests to its host and blocks the host for its Retry-After,
+// every other response halves the interval until the host is not throttled anymore
+// it is safe for concurrent use
+type throttle struct {
+	mu    sync.Mutex
+	hosts map[string]*hostRate
+}
+
+// hostRate is the throttling state of a host
+type hostRate struct {
+	interval time.Duration
+	//next is the earliest time of the next request
+	next time.Time
+}
+
+func newThrottle() *throttle {
+	return &throttle{hosts: map[string]*hostRate{}}
+}
+
+// wait blocks until the next request to host may be sent or ctx is done
+// parallel requests each reserve the next free slot, so they are spaced by the interval
+func (t *throttle) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	h, ok := t.hosts[strings.ToLower(host)]
+	if !ok {
+		t.mu.Unlock()
+		return nil
+	}
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(h.interval)
+	t.mu.Unlock()
+
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe adapts the rate of host to the status and Retry-After of res
+func (t *throttle) observe(host string, res *http.Response) {
+	host = strings.ToLower(host)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.hosts[host]
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		if ok {
+			h.interval /= 2
+			if h.interval < minThrottleInterval {
+				delete(t.hosts, host)
+			}
+		}
+		return
+	}
+	if !ok {
+		h = &hostRate{}
+		t.hosts[host] = h
+	}
+	h.interval *= 2
+	if h.interval < minThrottleInterval {
+		h.interval = minThrottleInterval
+	}
+	if h.interval > maxThrottleInterval {
+		h.interval = maxThrottleInterval
+	}
+	now := time.Now()
+	next := now.Add(h.interval)
+	if d, ok := retryAfter(res.Header, now); ok && now.Add(d).After(next) {
+		next = now.Add(d)
+	}
+	if next.After(h.next) {
+		h.next = next
+	}
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or a http date
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return 0, false
+		}
+		return time.Duration(s) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	arrivals := map[string]time.Time{}
+	throttled := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals[r.URL.Path] = time.Now()
+		first := !throttled && r.URL.Path == "/a"
+		throttled = throttled || first
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`<html><body><a href="/a">A</a> <a href="/b">B</a></body></html>`))
+	}))
+	defer ts.Close()
+
+	defer func(orig *throttle) { requestThrottle = orig }(requestThrottle)
+	requestThrottle = newThrottle()
+	pages, err := (&crawler{maxPages: 10, maxDepth: 1}).crawl(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || pages[1].status != http.StatusTooManyRequests {
+		t.Fatalf("expected /, a throttled /a and /b, got %+v", pages)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if d := arrivals["/b"].Sub(arrivals["/a"]); d < time.Second {
+		t.Errorf("expected the crawler to wait the Retry-After of 1s before the next request, waited %s", d)
+	}
+	//the healthy response of /b speeds up again
+	if n := len(requestThrottle.hosts); n != 0 {
+		t.Errorf("expected the host not to be throttled after a healthy response, got %d throttled hosts", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(http.Header{"Retry-After": {tt.value}}, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: expected %s %t, got %s %t", tt.value, tt.want, tt.ok, got, ok)
+		}
+	}
+}
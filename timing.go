@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// measureTiming reports the timings of the page and its links, pingLink then reads the bodies of links to measure their total duration
+// run sets it from --ttfb
+var measureTiming bool
+
+// requestTiming contains the time to first byte and the total duration of a request until its body was read
+// both start before the first request, so they include the redirects
+type requestTiming struct {
+	ttfb  time.Duration
+	total time.Duration
+}
+
+func (t requestTiming) String() string {
+	return fmt.Sprintf("TTFB %s, total %s", t.ttfb.Round(time.Millisecond), t.total.Round(time.Millisecond))
+}
+
+// timedBody is the body of a response sent by doRequest, it records the timing of the request
+type timedBody struct {
+	io.ReadCloser
+	start time.Time
+	requestTiming
+}
+
+// Read stops the total duration when the body was read to the end and counts the bytes in totalBytes
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if totalBytes != nil {
+		totalBytes.add(n)
+	}
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.done()
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) done() {
+	if b.total == 0 {
+		b.total = time.Since(b.start)
+	}
+}
+
+// traceTiming returns req with a trace recording the time to first byte into the returned timedBody
+// the transport calls GotFirstResponseByte before client.Do returns
+func traceTiming(req *http.Request) (*http.Request, *timedBody) {
+	b := &timedBody{start: time.Now()}
+	trace := &httptrace.ClientTrace{GotFirstResponseByte: func() {
+		b.ttfb = time.Since(b.start)
+	}}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), b
+}
+
+// responseTiming returns the timing of a response sent by doRequest
+// the total duration is only known after the body was read or closed
+func responseTiming(res *http.Response) requestTiming {
+	if b, ok := res.Body.(*timedBody); ok {
+		return b.requestTiming
+	}
+	return requestTiming{}
+}
+
+// writeLinkTimings prints the timings of the checked links, up to maxExamples
+func writeLinkTimings(w io.Writer, links []linkResult, maxExamples int) {
+	timings := []string{}
+	for _, l := range links {
+		//links with an error or which were not checked have no response
+		if l.code != 0 {
+			timings = append(timings, fmt.Sprintf("%s: %s", l.url, l.timing))
+		}
+	}
+	fmt.Fprintln(w, "Link timings:")
+	writeExamples(w, "  ", timings, maxExamples)
+}
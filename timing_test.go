@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTTFB(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//the headers are delayed by 50ms and the body by another 100ms
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("<html><head><title>Slow body</title></head><body></body></html>"))
+	}))
+	defer ts.Close()
+
+	check := func(name string, timing requestTiming) {
+		t.Helper()
+		if timing.ttfb < 50*time.Millisecond || timing.ttfb >= 150*time.Millisecond {
+			t.Errorf("%s: expected a TTFB between 50ms and 150ms, got %s", name, timing)
+		}
+		if timing.total-timing.ttfb < 100*time.Millisecond {
+			t.Errorf("%s: expected the total to include the 100ms of the body, got %s", name, timing)
+		}
+	}
+
+	fr, err := analyzePage(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("page", fr.timing)
+
+	defer func() { measureTiming = false }()
+	measureTiming = true
+	results := (&linkChecker{workers: 1}).check(context.Background(), []string{ts.URL + "/link"})
+	check("link", results[0].timing)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// tocEntry is a heading of the page with the fragment linking to it, anchor is "" if there is none
+type tocEntry struct {
+	level  int
+	text   string
+	anchor string
+}
+
+// getTOCEntries returns the h1-h6 in document order with the nearest id:
+// the heading's own, the one of an anchor inside it or of the closest wrapping element, like <section id>
+func getTOCEntries(doc *goquery.Document) []tocEntry {
+	entries := []tocEntry{}
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(i int, s *goquery.Selection) {
+		e := tocEntry{
+			level: int(goquery.NodeName(s)[1] - '0'),
+			text:  strings.Join(strings.Fields(s.Text()), " "),
+		}
+		id := strings.TrimSpace(s.AttrOr("id", ""))
+		if id == "" {
+			inner := s.Find("[id], a[name]").First()
+			id = strings.TrimSpace(inner.AttrOr("id", inner.AttrOr("name", "")))
+		}
+		if id == "" {
+			id = strings.TrimSpace(s.ParentsFiltered("[id]").First().AttrOr("id", ""))
+		}
+		if id != "" {
+			e.anchor = "#" + id
+		}
+		entries = append(entries, e)
+	})
+	return entries
+}
+
+// writeTOC prints the entries indented by level, headings without anchor are marked
+func writeTOC(w io.Writer, entries []tocEntry) {
+	fmt.Fprintln(w, "Table of contents:")
+	for _, e := range entries {
+		anchor := e.anchor
+		if anchor == "" {
+			anchor = "(no anchor)"
+		}
+		fmt.Fprintf(w, "%s%s %s\n", strings.Repeat("  ", e.level-1), e.text, anchor)
+	}
+}
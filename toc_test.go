@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTOCEntries(t *testing.T) {
+	fr := fetch(loadFixture(t, "toc.html"))
+	want := []tocEntry{
+		{level: 1, text: "Guide", anchor: "#guide"},
+		{level: 2, text: "Installation", anchor: "#install"},
+		{level: 3, text: "Requirements", anchor: "#requirements"},
+		{level: 2, text: "Usage", anchor: "#usage"},
+		{level: 2, text: "Changelog", anchor: ""},
+	}
+	if !reflect.DeepEqual(fr.toc, want) {
+		t.Errorf("expected %v, got %v", want, fr.toc)
+	}
+
+	var b bytes.Buffer
+	writeTOC(&b, fr.toc)
+	wantText := "Table of contents:\n" +
+		"Guide #guide\n" +
+		"  Installation #install\n" +
+		"    Requirements #requirements\n" +
+		"  Usage #usage\n" +
+		"  Changelog (no anchor)\n"
+	if b.String() != wantText {
+		t.Errorf("expected\n%s\ngot\n%s", wantText, b.String())
+	}
+}
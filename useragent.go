@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// uaProfile contains the headers a well known client sends
+type uaProfile struct {
+	userAgent string
+	accept    string
+}
+
+const acceptHTML = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+
+var uaProfiles = map[string]uaProfile{
+	"googlebot": {
+		userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		accept:    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	},
+	"bingbot": {
+		userAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+		accept:    "*/*",
+	},
+	"chrome-desktop": {
+		userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		accept:    acceptHTML,
+	},
+	"chrome-mobile": {
+		userAgent: "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+		accept:    acceptHTML,
+	},
+	"safari-ios": {
+		userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+		accept:    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	},
+}
+
+// uaProfileNames returns the sorted names of all presets
+func uaProfileNames() []string {
+	names := []string{}
+	for n := range uaProfiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setUserAgent sets the headers of profile in requestHeaders, a custom User-Agent overrides the profile's
+// both empty keeps the app's own User-Agent
+func setUserAgent(profile, custom string) error {
+	if profile != "" {
+		p, ok := uaProfiles[profile]
+		if !ok {
+			return fmt.Errorf("unknown ua profile %q, use one of %v", profile, uaProfileNames())
+		}
+		requestHeaders.Set("User-Agent", p.userAgent)
+		requestHeaders.Set("Accept", p.accept)
+	}
+	if custom != "" {
+		requestHeaders.Set("User-Agent", custom)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withHeaders restores requestHeaders after a test changed them
+func withHeaders(t *testing.T) {
+	orig := requestHeaders.Clone()
+	t.Cleanup(func() { requestHeaders = orig })
+}
+
+func TestUserAgentProfiles(t *testing.T) {
+	var gotUA, gotAccept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA, gotAccept = r.UserAgent(), r.Header.Get("Accept")
+	}))
+	defer ts.Close()
+
+	withHeaders(t)
+	for _, name := range uaProfileNames() {
+		if err := setUserAgent(name, ""); err != nil {
+			t.Fatal(err)
+		}
+		res, err := get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if gotUA != uaProfiles[name].userAgent {
+			t.Errorf("%s: expected User-Agent %q, got %q", name, uaProfiles[name].userAgent, gotUA)
+		}
+		if gotAccept != uaProfiles[name].accept {
+			t.Errorf("%s: expected Accept %q, got %q", name, uaProfiles[name].accept, gotAccept)
+		}
+	}
+}
+
+func TestCustomUserAgentOverridesProfile(t *testing.T) {
+	withHeaders(t)
+	if err := setUserAgent("googlebot", "my-agent/1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if ua := requestHeaders.Get("User-Agent"); ua != "my-agent/1.0" {
+		t.Fatalf("expected custom User-Agent, got %q", ua)
+	}
+	if err := setUserAgent("netscape", ""); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+}